@@ -2,26 +2,146 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/ViBiOh/auth/v3/internal/terminal"
 	"github.com/ViBiOh/auth/v3/pkg/argon"
+	"github.com/ViBiOh/flags"
 	"github.com/ViBiOh/httputils/v4/pkg/logger"
 )
 
+const usage = `Usage: argon <command> [flags]
+
+Commands:
+  hash       Hash a password read from stdin or prompted on a terminal
+  verify     Verify a password read from stdin or prompted on a terminal against an encoded hash
+  calibrate  Benchmark the machine and recommend parameters for a target latency
+`
+
 func main() {
-	flag.Parse()
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+
+	var err error
 
-	if len(os.Args) != 2 {
-		fmt.Println("Usage is argon [password]")
+	switch command, args := os.Args[1], os.Args[2:]; command {
+	case "hash":
+		err = hash(args)
+	case "verify":
+		err = verify(args)
+	case "calibrate":
+		err = calibrate(args)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if errors.Is(err, argon.ErrHashDontMatch) {
+		fmt.Fprintln(os.Stderr, "Password doesn't match")
 		os.Exit(1)
 	}
 
-	ctx := context.Background()
+	logger.FatalfOnErr(ctx, err, os.Args[1])
+}
+
+func hash(args []string) error {
+	fs := flag.NewFlagSet("hash", flag.ExitOnError)
+	fs.Usage = flags.Usage(fs)
+
+	argonConfig := argon.Flags(fs, "")
 
-	encodedHash, err := argon.GenerateFromPassword(os.Args[1])
-	logger.FatalfOnErr(ctx, err, "generate")
+	_ = fs.Parse(args)
+
+	params, err := argon.NewParams(argonConfig)
+	if err != nil {
+		return fmt.Errorf("params: %w", err)
+	}
+
+	password, err := terminal.ReadPassword(true)
+	if err != nil {
+		return fmt.Errorf("read password: %w", err)
+	}
+
+	encodedHash, err := params.GenerateFromPassword(password)
+	if err != nil {
+		return fmt.Errorf("generate: %w", err)
+	}
 
 	fmt.Println(encodedHash)
+
+	return nil
+}
+
+func verify(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	fs.Usage = flags.Usage(fs)
+
+	argonConfig := argon.Flags(fs, "")
+	encoded := flags.New("Hash", "Encoded argon2id hash to verify against").DocPrefix("argon").String(fs, "", nil)
+
+	_ = fs.Parse(args)
+
+	if len(*encoded) == 0 {
+		return errors.New("hash is required")
+	}
+
+	params, err := argon.NewParams(argonConfig)
+	if err != nil {
+		return fmt.Errorf("params: %w", err)
+	}
+
+	password, err := terminal.ReadPassword(false)
+	if err != nil {
+		return fmt.Errorf("read password: %w", err)
+	}
+
+	if err := params.CompareHashAndPassword(*encoded, password); err != nil {
+		return err
+	}
+
+	if params.NeedsRehash(*encoded) {
+		fmt.Println("Password matches, but the hash should be upgraded to the current parameters")
+	} else {
+		fmt.Println("Password matches")
+	}
+
+	return nil
+}
+
+func calibrate(args []string) error {
+	fs := flag.NewFlagSet("calibrate", flag.ExitOnError)
+	fs.Usage = flags.Usage(fs)
+
+	target := flags.New("Target", "Targeted hashing latency").DocPrefix("argon").Duration(fs, time.Millisecond*500, nil)
+	maxMemory := flags.New("MaxMemory", "Maximum memory used for hashing, in KiB").DocPrefix("argon").Uint(fs, 1024*1024, nil)
+	parallelism := flags.New("Parallelism", "Number of threads used for hashing").DocPrefix("argon").Uint(fs, argon.Parallelism, nil)
+
+	_ = fs.Parse(args)
+
+	if *parallelism < 1 || *parallelism > 1<<8-1 {
+		return errors.New("parallelism must be between 1 and 255")
+	}
+
+	if *maxMemory < argon.Memory || *maxMemory > 1<<32-1 {
+		return fmt.Errorf("max memory must be between %d KiB and 4 TiB", argon.Memory)
+	}
+
+	fmt.Fprintf(os.Stderr, "Calibrating for %s, it may take a while...\n", *target)
+
+	params, latency := argon.Calibrate(*target, uint32(*maxMemory), uint8(*parallelism))
+	if latency > *target {
+		fmt.Fprintf(os.Stderr, "Minimal parameters already take %s, consider raising the target\n", latency)
+	}
+
+	fmt.Printf("-memory %d -iterations %d -parallelism %d # %s\n", params.Memory, params.Iterations, params.Parallelism, latency.Round(time.Millisecond))
+
+	return nil
 }
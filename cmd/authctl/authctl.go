@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/ViBiOh/auth/v3/pkg/argon"
+	"github.com/ViBiOh/auth/v3/pkg/password"
+	dbStore "github.com/ViBiOh/auth/v3/pkg/store/db"
+	"github.com/ViBiOh/flags"
+	"github.com/ViBiOh/httputils/v4/pkg/db"
+	"github.com/ViBiOh/httputils/v4/pkg/logger"
+)
+
+type command struct {
+	name        string
+	description string
+}
+
+var commands = []command{
+	{"user create", "Create a basic user, password being read from stdin or prompted on a terminal"},
+	{"user password", "Reset the password of a basic user and revoke its sessions"},
+	{"user list", "List users of all kinds"},
+	{"user delete", "Delete a user and all its identities"},
+	{"invite create", "Create an invite and print its token"},
+	{"invite list", "List invites"},
+	{"invite revoke", "Revoke an invite"},
+	{"profile create", "Create a profile"},
+	{"profile grant", "Grant profiles to a user"},
+	{"profile revoke", "Revoke profiles from a user"},
+}
+
+type app struct {
+	store   dbStore.Service
+	policy  password.Policy
+	printer printer
+}
+
+func main() {
+	fs := flag.NewFlagSet("authctl", flag.ExitOnError)
+	fs.Usage = usage(fs)
+
+	loggerConfig := logger.Flags(fs, "logger")
+	dbConfig := db.Flags(fs, "db")
+	argonConfig := argon.Flags(fs, "argon")
+	passwordConfig := password.Flags(fs, "password")
+
+	output := flags.New("Output", "Output format, table or json").DocPrefix("authctl").String(fs, "table", nil)
+
+	_ = fs.Parse(os.Args[1:])
+
+	args := fs.Args()
+	if len(args) < 2 || !slices.ContainsFunc(commands, func(item command) bool { return item.name == args[0]+" "+args[1] }) {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(os.Stderr, "unhandled output `%s`\n", *output)
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+
+	logger.Init(ctx, loggerConfig)
+
+	appDB, err := db.New(ctx, dbConfig, nil)
+	logger.FatalfOnErr(ctx, err, "create db")
+
+	defer appDB.Close()
+
+	hasher, err := argon.NewHasher(argonConfig, nil)
+	logger.FatalfOnErr(ctx, err, "argon")
+
+	policy, err := password.NewPolicy(passwordConfig)
+	logger.FatalfOnErr(ctx, err, "password policy")
+
+	cli := app{
+		store:   dbStore.New(appDB, dbStore.WithHasher(hasher)),
+		policy:  policy,
+		printer: printer{writer: os.Stdout, json: *output == "json"},
+	}
+
+	name := args[0] + " " + args[1]
+	logger.FatalfOnErr(ctx, cli.run(ctx, name, args[2:]), name)
+}
+
+func usage(fs *flag.FlagSet) func() {
+	flagsUsage := flags.Usage(fs)
+
+	return func() {
+		fmt.Fprintf(fs.Output(), "Usage: authctl [flags] <command> [command flags]\n\nCommands:\n")
+
+		for _, item := range commands {
+			fmt.Fprintf(fs.Output(), "  %-16s %s\n", item.name, item.description)
+		}
+
+		fmt.Fprintln(fs.Output())
+		flagsUsage()
+	}
+}
+
+func (a app) run(ctx context.Context, name string, args []string) error {
+	// flag set name prefixes environment variables, e.g. USER_CREATE_LOGIN
+	group, action, _ := strings.Cut(name, " ")
+
+	fs := flag.NewFlagSet(group+strings.ToUpper(action[:1])+action[1:], flag.ExitOnError)
+	fs.Usage = flags.Usage(fs)
+
+	switch name {
+	case "user create":
+		return a.createUser(ctx, fs, args)
+	case "user password":
+		return a.resetPassword(ctx, fs, args)
+	case "user list":
+		return a.listUsers(ctx, fs, args)
+	case "user delete":
+		return a.deleteUser(ctx, fs, args)
+	case "invite create":
+		return a.createInvite(ctx, fs, args)
+	case "invite list":
+		return a.listInvites(ctx, fs, args)
+	case "invite revoke":
+		return a.revokeInvite(ctx, fs, args)
+	case "profile create":
+		return a.createProfile(ctx, fs, args)
+	case "profile grant":
+		return a.grantProfiles(ctx, fs, args)
+	case "profile revoke":
+		return a.revokeProfiles(ctx, fs, args)
+	default:
+		return fmt.Errorf("unknown command `%s`", name)
+	}
+}
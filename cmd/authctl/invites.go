@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ViBiOh/auth/v3/pkg/model"
+	"github.com/ViBiOh/flags"
+)
+
+type createdInvite struct {
+	model.Invitation
+	Token string `json:"token"`
+}
+
+func (a app) createInvite(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	description := flags.New("Description", "Description of the invite").DocPrefix("invite").String(fs, "", nil)
+	maxUses := flags.New("MaxUses", "Number of accounts the invite can create").DocPrefix("invite").Int(fs, 1, nil)
+	expiration := flags.New("Expiration", "Validity of the invite, 0 for no expiration").DocPrefix("invite").Duration(fs, 0, nil)
+	provider := flags.New("Provider", "Only provider allowed to use the invite").DocPrefix("invite").String(fs, "", nil)
+	email := flags.New("Email", "Email the invite is intended for").DocPrefix("invite").String(fs, "", nil)
+	profiles := flags.New("Profile", "Profile granted to users of the invite, repeatable").DocPrefix("invite").StringSlice(fs, nil, nil)
+
+	_ = fs.Parse(args)
+
+	if len(*description) == 0 {
+		return errors.New("description is required")
+	}
+
+	invitation := model.Invitation{
+		Description: *description,
+		MaxUses:     *maxUses,
+		Provider:    *provider,
+		Email:       *email,
+		Profiles:    *profiles,
+		Creation:    time.Now(),
+	}
+
+	if *expiration > 0 {
+		invitation.Expiration = time.Now().Add(*expiration)
+	}
+
+	if err := a.store.DoAtomic(ctx, func(ctx context.Context) (err error) {
+		invitation, err = a.store.CreateInvite(ctx, invitation)
+		return err
+	}); err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+
+	return a.printer.print(createdInvite{Invitation: invitation, Token: invitation.Token}, []string{"ID", "DESCRIPTION", "TOKEN"}, []string{invitation.UserID, invitation.Description, invitation.Token})
+}
+
+func (a app) listInvites(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	_ = fs.Parse(args)
+
+	invitations, err := a.store.ListInvitations(ctx)
+	if err != nil {
+		return fmt.Errorf("list: %w", err)
+	}
+
+	if invitations == nil {
+		invitations = []model.Invitation{}
+	}
+
+	now := time.Now()
+
+	rows := make([][]string, 0, len(invitations))
+	for _, invitation := range invitations {
+		expiration := "never"
+		if !invitation.Expiration.IsZero() {
+			expiration = invitation.Expiration.Format(time.RFC3339)
+		}
+
+		rows = append(rows, []string{
+			invitation.UserID,
+			invitation.Description,
+			invitation.Status(now),
+			strconv.Itoa(invitation.Uses) + "/" + strconv.Itoa(invitation.MaxUses),
+			expiration,
+			strings.Join(invitation.Profiles, ","),
+		})
+	}
+
+	return a.printer.print(invitations, []string{"ID", "DESCRIPTION", "STATUS", "USES", "EXPIRATION", "PROFILES"}, rows...)
+}
+
+func (a app) revokeInvite(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	id := flags.New("ID", "Identifier of the invite").DocPrefix("invite").String(fs, "", nil)
+
+	_ = fs.Parse(args)
+
+	if len(*id) == 0 {
+		return errors.New("id is required")
+	}
+
+	if err := a.store.RevokeInvite(ctx, model.Invitation{UserID: *id}); err != nil {
+		return fmt.Errorf("revoke: %w", err)
+	}
+
+	return a.printer.result(*id, "revoked")
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/ViBiOh/auth/v3/pkg/mocks"
+	dbStore "github.com/ViBiOh/auth/v3/pkg/store/db"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateInvite(t *testing.T) {
+	t.Parallel()
+
+	errInsert := errors.New("foreign key violation")
+
+	cases := map[string]struct {
+		args      []string
+		insertErr error
+		wantOut   string
+		wantErr   bool
+	}{
+		"created": {
+			[]string{"-description", "New colleague"},
+			nil,
+			"New colleague",
+			false,
+		},
+		"no description": {
+			nil,
+			nil,
+			"",
+			true,
+		},
+		"insert error": {
+			[]string{"-description", "New colleague"},
+			errInsert,
+			"",
+			true,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+
+			mockDatabase := mocks.NewDatabase(ctrl)
+
+			var output bytes.Buffer
+
+			instance := app{
+				store:   dbStore.New(mockDatabase),
+				printer: printer{writer: &output},
+			}
+
+			switch intention {
+			case "created", "insert error":
+				mockDatabase.EXPECT().DoAtomic(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, action func(context.Context) error) error {
+					return action(ctx)
+				})
+				mockDatabase.EXPECT().One(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				mockDatabase.EXPECT().One(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), "New colleague", gomock.Any(), 1, "", gomock.Any(), gomock.Nil(), "").Return(testCase.insertErr)
+			}
+
+			gotErr := instance.createInvite(context.Background(), flag.NewFlagSet(t.Name(), flag.ContinueOnError), testCase.args)
+
+			if (gotErr != nil) != testCase.wantErr || (testCase.insertErr != nil && !errors.Is(gotErr, testCase.insertErr)) {
+				t.Errorf("createInvite() = `%v`, want error %t", gotErr, testCase.wantErr)
+			}
+
+			if !strings.Contains(output.String(), testCase.wantOut) {
+				t.Errorf("createInvite() output = `%s`, want `%s`", output.String(), testCase.wantOut)
+			}
+		})
+	}
+}
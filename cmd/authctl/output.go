@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+type result struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+type printer struct {
+	writer io.Writer
+	json   bool
+}
+
+func (p printer) print(value any, header []string, rows ...[]string) error {
+	if p.json {
+		encoder := json.NewEncoder(p.writer)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(value)
+	}
+
+	writer := tabwriter.NewWriter(p.writer, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(writer, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+
+	return writer.Flush()
+}
+
+func (p printer) result(id, status string) error {
+	return p.print(result{ID: id, Status: status}, []string{"ID", "STATUS"}, []string{id, status})
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/ViBiOh/auth/v3/pkg/model"
+	"github.com/ViBiOh/flags"
+)
+
+func (a app) createProfile(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	name := flags.New("Name", "Name of the profile").DocPrefix("profile").String(fs, "", nil)
+
+	_ = fs.Parse(args)
+
+	if len(*name) == 0 {
+		return errors.New("name is required")
+	}
+
+	if err := a.store.CreateProfile(ctx, *name); err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+
+	return a.printer.result(*name, "created")
+}
+
+func (a app) grantProfiles(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	user, profiles, err := parseProfiles(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if err := a.store.GrantProfiles(ctx, user, profiles); err != nil {
+		return fmt.Errorf("grant: %w", err)
+	}
+
+	return a.printer.result(user.ID, "granted")
+}
+
+func (a app) revokeProfiles(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	user, profiles, err := parseProfiles(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if err := a.store.RevokeProfiles(ctx, user, profiles); err != nil {
+		return fmt.Errorf("revoke: %w", err)
+	}
+
+	return a.printer.result(user.ID, "revoked")
+}
+
+func parseProfiles(fs *flag.FlagSet, args []string) (model.User, []string, error) {
+	id := flags.New("ID", "Identifier of the user").DocPrefix("profile").String(fs, "", nil)
+	profiles := flags.New("Profile", "Name of the profile, repeatable").DocPrefix("profile").StringSlice(fs, nil, nil)
+
+	_ = fs.Parse(args)
+
+	if len(*id) == 0 {
+		return model.User{}, nil, errors.New("id is required")
+	}
+
+	if len(*profiles) == 0 {
+		return model.User{}, nil, errors.New("at least one profile is required")
+	}
+
+	return model.User{ID: *id}, *profiles, nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/ViBiOh/auth/v3/internal/terminal"
+	"github.com/ViBiOh/auth/v3/pkg/model"
+	"github.com/ViBiOh/flags"
+)
+
+func (a app) createUser(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	login := flags.New("Login", "Login of the user").DocPrefix("user").String(fs, "", nil)
+	profiles := flags.New("Profile", "Profile granted to the user, repeatable").DocPrefix("user").StringSlice(fs, nil, nil)
+
+	_ = fs.Parse(args)
+
+	if len(*login) == 0 {
+		return errors.New("login is required")
+	}
+
+	name := strings.ToLower(*login)
+
+	password, err := terminal.ReadPassword(true)
+	if err != nil {
+		return fmt.Errorf("read password: %w", err)
+	}
+
+	if err := a.policy.Validate(ctx, name, password); err != nil {
+		return err
+	}
+
+	var user model.User
+
+	if err := a.store.DoAtomic(ctx, func(ctx context.Context) error {
+		user, err = a.store.CreateBasic(ctx, name, password)
+		if err != nil {
+			return fmt.Errorf("create: %w", err)
+		}
+
+		if err := a.store.GrantProfiles(ctx, user, *profiles); err != nil {
+			return fmt.Errorf("grant profiles: %w", err)
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return a.printer.result(user.ID, "created")
+}
+
+func (a app) resetPassword(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	login := flags.New("Login", "Login of the user").DocPrefix("user").String(fs, "", nil)
+
+	_ = fs.Parse(args)
+
+	if len(*login) == 0 {
+		return errors.New("login is required")
+	}
+
+	name := strings.ToLower(*login)
+
+	user, err := a.store.GetBasicUserByLogin(ctx, name)
+	if err != nil {
+		return fmt.Errorf("get user: %w", err)
+	}
+
+	password, err := terminal.ReadPassword(true)
+	if err != nil {
+		return fmt.Errorf("read password: %w", err)
+	}
+
+	if err := a.policy.Validate(ctx, name, password); err != nil {
+		return err
+	}
+
+	if err := a.store.DoAtomic(ctx, func(ctx context.Context) error {
+		if err := a.store.UpdatePassword(ctx, user, password); err != nil {
+			return fmt.Errorf("update password: %w", err)
+		}
+
+		if err := a.store.RevokeSessions(ctx, user); err != nil {
+			return fmt.Errorf("revoke sessions: %w", err)
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return a.printer.result(user.ID, "updated")
+}
+
+func (a app) listUsers(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	kind := flags.New("Kind", "Only list users having an identity of this kind").DocPrefix("user").String(fs, "", nil)
+
+	_ = fs.Parse(args)
+
+	ids, err := a.store.ListIDs(ctx)
+	if err != nil {
+		return fmt.Errorf("list ids: %w", err)
+	}
+
+	users, err := a.store.List(ctx, ids...)
+	if err != nil {
+		return fmt.Errorf("list: %w", err)
+	}
+
+	if len(*kind) != 0 {
+		wanted, err := model.ParseUserKind(*kind)
+		if err != nil {
+			return err
+		}
+
+		users = filterKind(users, wanted)
+	}
+
+	if users == nil {
+		users = []model.User{}
+	}
+
+	rows := make([][]string, 0, len(users))
+	for _, user := range users {
+		kinds := make([]string, 0, len(user.Identities))
+		for _, identity := range user.Identities {
+			kinds = append(kinds, identity.Kind.String())
+		}
+
+		rows = append(rows, []string{user.ID, user.Name, strings.Join(kinds, ","), user.Email})
+	}
+
+	return a.printer.print(users, []string{"ID", "NAME", "KINDS", "EMAIL"}, rows...)
+}
+
+func filterKind(users []model.User, kind model.UserKind) []model.User {
+	var output []model.User
+
+	for _, user := range users {
+		for _, identity := range user.Identities {
+			if identity.Kind == kind {
+				output = append(output, user)
+				break
+			}
+		}
+	}
+
+	return output
+}
+
+func (a app) deleteUser(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	id := flags.New("ID", "Identifier of the user").DocPrefix("user").String(fs, "", nil)
+
+	_ = fs.Parse(args)
+
+	if len(*id) == 0 {
+		return errors.New("id is required")
+	}
+
+	if err := a.store.Delete(ctx, model.User{ID: *id}); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	return a.printer.result(*id, "deleted")
+}
@@ -2,17 +2,30 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
+	"log/slog"
+	"net/http"
 	"os"
+	"time"
 
+	"github.com/ViBiOh/auth/v3/pkg/argon"
+	"github.com/ViBiOh/auth/v3/pkg/cookie"
 	"github.com/ViBiOh/auth/v3/pkg/middleware"
+	"github.com/ViBiOh/auth/v3/pkg/model"
+	"github.com/ViBiOh/auth/v3/pkg/password"
 	"github.com/ViBiOh/auth/v3/pkg/provider/basic"
+	"github.com/ViBiOh/auth/v3/pkg/provider/webauthn"
+	"github.com/ViBiOh/auth/v3/pkg/recovery"
+	"github.com/ViBiOh/auth/v3/pkg/redirect"
 	dbStore "github.com/ViBiOh/auth/v3/pkg/store/db"
+	"github.com/ViBiOh/auth/v3/pkg/totp"
 	"github.com/ViBiOh/flags"
 	"github.com/ViBiOh/httputils/v4/pkg/db"
 	"github.com/ViBiOh/httputils/v4/pkg/health"
 	"github.com/ViBiOh/httputils/v4/pkg/httputils"
 	"github.com/ViBiOh/httputils/v4/pkg/logger"
+	"github.com/ViBiOh/httputils/v4/pkg/redis"
 	"github.com/ViBiOh/httputils/v4/pkg/server"
 )
 
@@ -25,6 +38,12 @@ func main() {
 
 	serverConfig := server.Flags(fs, "")
 	dbConfig := db.Flags(fs, "db")
+	cookieConfig := cookie.Flags(fs, "cookie")
+	totpConfig := totp.Flags(fs, "totp")
+	passwordConfig := password.Flags(fs, "password")
+	argonConfig := argon.Flags(fs, "argon")
+	redisConfig := redis.Flags(fs, "redis")
+	webauthnConfig := webauthn.Flags(fs, "webauthn")
 
 	_ = fs.Parse(os.Args[1:])
 
@@ -39,12 +58,59 @@ func main() {
 
 	healthService := health.New(ctx, healthConfig, appDB.Ping)
 
-	authProvider := dbStore.New(appDB)
-	identProvider := basic.New(authProvider)
+	redisClient, err := redis.New(ctx, redisConfig, nil, nil)
+	logger.FatalfOnErr(ctx, err, "redis")
+
+	hasher, err := argon.NewHasher(argonConfig, nil)
+	logger.FatalfOnErr(ctx, err, "argon")
+
+	verificationCache := basic.NewVerificationCache(time.Minute)
+
+	authProvider := dbStore.New(appDB, dbStore.WithHasher(hasher), dbStore.WithPasswordUpdateHandler(verificationCache.Invalidate))
+
+	basicOptions := []basic.Option{basic.WithCookie(cookie.New[model.User](cookieConfig)), basic.WithSessionRevocation(authProvider), basic.WithVerificationCache(verificationCache)}
+
+	recoveryService := recovery.New(authProvider, hasher)
+	webauthnService := webauthn.New(webauthnConfig, redisClient, authProvider, nil, cookie.Service[model.OAuthClaim]{}, redirect.Service{})
+
+	totpService, err := totp.New(totpConfig, authProvider)
+	totpEnabled := err == nil
+
+	if totpEnabled {
+		basicOptions = append(basicOptions, basic.WithSecondFactor(basic.AnyOf(webauthnService, recoveryService.Fallback(totpService))))
+	} else if errors.Is(err, totp.ErrNoKey) {
+		basicOptions = append(basicOptions, basic.WithSecondFactor(webauthnService))
+	} else {
+		logger.FatalfOnErr(ctx, err, "totp")
+	}
+
+	identProvider := basic.New(authProvider, basicOptions...)
 	middlewareApp := middleware.New(identProvider)
 
+	passwordService, err := password.New(passwordConfig, authProvider, password.NotifierFunc(func(ctx context.Context, user model.User, _ string) error {
+		slog.LogAttrs(ctx, slog.LevelWarn, "password reset requested but delivery is not configured", slog.String("id", user.ID))
+		return nil
+	}))
+	logger.FatalfOnErr(ctx, err, "password")
+
+	authMux := http.NewServeMux()
+	passwordService.Mux("/password", authMux)
+	webauthnService.CredentialsMux("/passkeys", authMux)
+
+	if totpEnabled {
+		totpService.Mux("/totp", authMux)
+		recoveryService.Mux("/recovery", authMux)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /login", identProvider.Login)
+	mux.HandleFunc("POST /login/otp", identProvider.LoginSecondFactor)
+	mux.HandleFunc("POST /login/webauthn/begin", webauthnService.BeginSecondFactor(identProvider.PendingUser))
+	passwordService.ResetMux("/password", mux)
+	mux.Handle("/", middlewareApp.Middleware(authMux))
+
 	appServer := server.New(serverConfig)
-	go appServer.Start(healthService.EndCtx(), httputils.Handler(nil, healthService, middlewareApp.Middleware))
+	go appServer.Start(healthService.EndCtx(), httputils.Handler(mux, healthService))
 
 	healthService.WaitForTermination(appServer.Done())
 	health.WaitAll(appServer.Done())
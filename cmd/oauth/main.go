@@ -8,14 +8,20 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/ViBiOh/auth/v3/pkg/cookie"
+	"github.com/ViBiOh/auth/v3/pkg/invite"
+	"github.com/ViBiOh/auth/v3/pkg/mailer"
 	"github.com/ViBiOh/auth/v3/pkg/middleware"
 	"github.com/ViBiOh/auth/v3/pkg/model"
 	"github.com/ViBiOh/auth/v3/pkg/provider/chooser"
 	"github.com/ViBiOh/auth/v3/pkg/provider/discord"
 	"github.com/ViBiOh/auth/v3/pkg/provider/github"
 	"github.com/ViBiOh/auth/v3/pkg/provider/google"
+	"github.com/ViBiOh/auth/v3/pkg/provider/magiclink"
+	"github.com/ViBiOh/auth/v3/pkg/provider/webauthn"
+	"github.com/ViBiOh/auth/v3/pkg/redirect"
 	dbStore "github.com/ViBiOh/auth/v3/pkg/store/db"
 	"github.com/ViBiOh/flags"
 	"github.com/ViBiOh/httputils/v4/pkg/db"
@@ -41,9 +47,14 @@ func main() {
 	serverConfig := server.Flags(fs, "")
 	redisConfig := redis.Flags(fs, "redis")
 	cookieConfig := cookie.Flags(fs, "cookie")
+	redirectConfig := redirect.Flags(fs, "redirect")
 	discordConfig := discord.Flags(fs, "discord")
 	githubConfig := github.Flags(fs, "github")
 	googleConfig := google.Flags(fs, "google")
+	inviteConfig := invite.Flags(fs, "invite")
+	mailerConfig := mailer.Flags(fs, "mailer")
+	magiclinkConfig := magiclink.Flags(fs, "magiclink")
+	webauthnConfig := webauthn.Flags(fs, "webauthn")
 	rendererConfig := renderer.Flags(fs, "", flags.NewOverride("Title", "OAuth"))
 	dbConfig := db.Flags(fs, "db")
 
@@ -63,12 +74,12 @@ func main() {
 
 	dbService := dbStore.New(database)
 
-	_, registration, err := dbService.CreateInvite(ctx, "nobody@localhost")
+	invitation, err := dbService.CreateInvite(ctx, model.Invitation{Description: "nobody@localhost", Expiration: time.Now().Add(time.Hour * 24), MaxUses: 1})
 	logger.FatalfOnErr(ctx, err, "create link")
 
-	fmt.Printf("Connect to http://127.0.0.1:%d/oauth/discord/register?registration=%s&redirect=/hello/world\n", serverConfig.Port, registration)
-	fmt.Printf("Connect to http://127.0.0.1:%d/oauth/github/register?registration=%s&redirect=/hello/world\n", serverConfig.Port, registration)
-	fmt.Printf("Connect to http://127.0.0.1:%d/oauth/google/register?registration=%s&redirect=/hello/world\n", serverConfig.Port, registration)
+	fmt.Printf("Connect to http://127.0.0.1:%d/oauth/discord/register?registration=%s&redirect=/hello/world\n", serverConfig.Port, invitation.Token)
+	fmt.Printf("Connect to http://127.0.0.1:%d/oauth/github/register?registration=%s&redirect=/hello/world\n", serverConfig.Port, invitation.Token)
+	fmt.Printf("Connect to http://127.0.0.1:%d/oauth/google/register?registration=%s&redirect=/hello/world\n", serverConfig.Port, invitation.Token)
 
 	rendererService, err := renderer.New(ctx, rendererConfig, content, nil, nil, nil)
 	logger.FatalfOnErr(ctx, err, "renderer")
@@ -76,19 +87,47 @@ func main() {
 	linkHandler := func(ctx context.Context, old, new model.User) error { return nil }
 
 	cookieService := cookie.New[model.OAuthClaim](cookieConfig)
-	discordService := discord.New(discordConfig, redisClient, dbService, linkHandler, rendererService, cookieService)
-	githubService := github.New(githubConfig, redisClient, dbService, linkHandler, rendererService, cookieService)
-	googleService := google.New(googleConfig, redisClient, dbService, linkHandler, rendererService, cookieService)
+	redirectService := redirect.New(redirectConfig)
+	discordService := discord.New(discordConfig, redisClient, dbService, linkHandler, rendererService, cookieService, redirectService)
+	githubService := github.New(githubConfig, redisClient, dbService, linkHandler, rendererService, cookieService, redirectService)
+	googleService := google.New(googleConfig, redisClient, dbService, linkHandler, rendererService, cookieService, redirectService)
+	webauthnService := webauthn.New(webauthnConfig, redisClient, dbService, rendererService, cookieService, redirectService)
 
 	discordPrefix := "/oauth/discord"
 	githubPrefix := "/oauth/github"
 	googlePrefix := "/oauth/google"
+	emailPrefix := "/oauth/email"
+	webauthnPrefix := "/oauth/webauthn"
+
+	chooserProviders := []chooser.Provider{
+		{Auth: discordService, Kind: model.Discord, RegisterPath: discordService.RegisterPath(discordPrefix), LinkPath: discordService.LinkPath(discordPrefix), UnlinkPath: discordService.UnlinkPath(discordPrefix)},
+		{Auth: githubService, Kind: model.GitHub, RegisterPath: githubService.RegisterPath(githubPrefix), LinkPath: githubService.LinkPath(githubPrefix), UnlinkPath: githubService.UnlinkPath(githubPrefix)},
+		{Auth: googleService, Kind: model.Google, RegisterPath: googleService.RegisterPath(googlePrefix), LinkPath: googleService.LinkPath(googlePrefix), UnlinkPath: googleService.UnlinkPath(googlePrefix)},
+		{Auth: webauthnService, Kind: model.Passkey, RegisterPath: webauthnService.RegisterPath(webauthnPrefix)},
+	}
+
+	inviteProviders := []invite.Provider{
+		{Name: discordService.Name(), RegisterPath: discordService.RegisterPath(discordPrefix)},
+		{Name: githubService.Name(), RegisterPath: githubService.RegisterPath(githubPrefix)},
+		{Name: googleService.Name(), RegisterPath: googleService.RegisterPath(googlePrefix)},
+	}
 
-	chooserService := chooser.New(rendererService,
-		chooser.Provider{Auth: discordService, Kind: model.Discord, RegisterPath: discordService.RegisterPath(discordPrefix)},
-		chooser.Provider{Auth: githubService, Kind: model.GitHub, RegisterPath: githubService.RegisterPath(githubPrefix)},
-		chooser.Provider{Auth: googleService, Kind: model.Google, RegisterPath: googleService.RegisterPath(googlePrefix)},
-	)
+	mux := http.NewServeMux()
+
+	var inviteOptions []invite.Option
+	if smtpMailer := mailer.New(mailerConfig); smtpMailer != nil {
+		inviteOptions = append(inviteOptions, invite.WithMailer(smtpMailer, rendererService))
+
+		magiclinkService, err := magiclink.New(magiclinkConfig, redisClient, dbService, smtpMailer, linkHandler, rendererService, cookieService, redirectService)
+		logger.FatalfOnErr(ctx, err, "magiclink")
+
+		magiclinkService.Mux(emailPrefix, mux)
+
+		chooserProviders = append(chooserProviders, chooser.Provider{Auth: magiclinkService, Kind: model.Email, RegisterPath: magiclinkService.RegisterPath(emailPrefix)})
+		inviteProviders = append(inviteProviders, invite.Provider{Name: magiclinkService.Name(), RegisterPath: magiclinkService.RegisterPath(emailPrefix)})
+	}
+
+	chooserService := chooser.New(rendererService, redirectService, chooserProviders...)
 
 	authMiddleware := middleware.New(chooserService)
 
@@ -103,12 +142,27 @@ func main() {
 		_, _ = fmt.Fprintf(w, "%s", payload)
 	})
 
-	mux := http.NewServeMux()
 	discordService.Mux(discordPrefix, mux)
 	githubService.Mux(githubPrefix, mux)
 	googleService.Mux(googlePrefix, mux)
+	webauthnService.Mux(webauthnPrefix, mux)
 
 	mux.Handle("/hello/world", authMiddleware.Middleware(authMux))
+	mux.HandleFunc("/account", chooserService.Account)
+
+	inviteService := invite.New(inviteConfig, dbService, inviteProviders, inviteOptions...)
+
+	inviteMux := http.NewServeMux()
+	inviteService.Mux("/invites", inviteMux)
+
+	mux.Handle("/invites", authMiddleware.Middleware(inviteMux))
+	mux.Handle("/invites/", authMiddleware.Middleware(inviteMux))
+
+	passkeysMux := http.NewServeMux()
+	webauthnService.CredentialsMux("/passkeys", passkeysMux)
+
+	mux.Handle("/passkeys", authMiddleware.Middleware(passkeysMux))
+	mux.Handle("/passkeys/", authMiddleware.Middleware(passkeysMux))
 
 	appServer := server.New(serverConfig)
 	go appServer.Start(healthService.EndCtx(), httputils.Handler(mux, healthService))
@@ -0,0 +1,88 @@
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type Cache struct {
+	content map[string][]byte
+	mutex   sync.Mutex
+}
+
+func NewCache() *Cache {
+	return &Cache{content: make(map[string][]byte)}
+}
+
+func (c *Cache) Load(_ context.Context, key string) ([]byte, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.content[key], nil
+}
+
+func (c *Cache) Store(_ context.Context, key string, value any, _ time.Duration) error {
+	var content []byte
+
+	switch value := value.(type) {
+	case []byte:
+		content = value
+	case string:
+		content = []byte(value)
+	default:
+		var err error
+		if content, err = json.Marshal(value); err != nil {
+			return fmt.Errorf("marshal: %w", err)
+		}
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.content[key] = content
+
+	return nil
+}
+
+func (c *Cache) Delete(_ context.Context, keys ...string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, key := range keys {
+		delete(c.content, key)
+	}
+
+	return nil
+}
+
+func (c *Cache) Exclusive(ctx context.Context, name string, _ time.Duration, action func(context.Context) error) (bool, error) {
+	c.mutex.Lock()
+	if _, ok := c.content[name]; ok {
+		c.mutex.Unlock()
+		return false, nil
+	}
+
+	c.content[name] = []byte("1")
+	c.mutex.Unlock()
+
+	defer func() {
+		_ = c.Delete(ctx, name)
+	}()
+
+	return true, action(ctx)
+}
+
+func (c *Cache) Keys() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	keys := make([]string, 0, len(c.content))
+	for key := range c.content {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
@@ -0,0 +1,34 @@
+package fake
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ViBiOh/auth/v3/pkg/mailer"
+)
+
+type Mailer struct {
+	err    error
+	emails []mailer.Email
+	mutex  sync.Mutex
+}
+
+func NewMailer(err error) *Mailer {
+	return &Mailer{err: err}
+}
+
+func (m *Mailer) Send(_ context.Context, email mailer.Email) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.emails = append(m.emails, email)
+
+	return m.err
+}
+
+func (m *Mailer) Emails() []mailer.Email {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return append([]mailer.Email(nil), m.emails...)
+}
@@ -0,0 +1,18 @@
+package fake
+
+import (
+	"html/template"
+	"io"
+)
+
+type Renderer struct {
+	template *template.Template
+}
+
+func NewRenderer(definitions string) Renderer {
+	return Renderer{template: template.Must(template.New("").Parse(definitions))}
+}
+
+func (r Renderer) Render(w io.Writer, name string, content any) error {
+	return r.template.ExecuteTemplate(w, name, content)
+}
@@ -0,0 +1,66 @@
+package terminal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+var ErrEmptyPassword = errors.New("empty password")
+
+// ReadPassword reads the first line of stdin, or prompts without echo when stdin is a terminal
+func ReadPassword(confirm bool) (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+
+		if password := strings.TrimRight(line, "\r\n"); len(password) != 0 {
+			return password, nil
+		}
+
+		return "", ErrEmptyPassword
+	}
+
+	password, err := prompt("Password: ")
+	if err != nil {
+		return "", err
+	}
+
+	if len(password) == 0 {
+		return "", ErrEmptyPassword
+	}
+
+	if !confirm {
+		return password, nil
+	}
+
+	confirmation, err := prompt("Confirm password: ")
+	if err != nil {
+		return "", err
+	}
+
+	if confirmation != password {
+		return "", errors.New("passwords don't match")
+	}
+
+	return password, nil
+}
+
+func prompt(label string) (string, error) {
+	fmt.Fprint(os.Stderr, label)
+	defer fmt.Fprintln(os.Stderr)
+
+	password, err := term.ReadPassword(int(os.Stdin.Fd()))
+	if err != nil {
+		return "", fmt.Errorf("read: %w", err)
+	}
+
+	return string(password), nil
+}
@@ -5,10 +5,14 @@ import (
 	"crypto/subtle"
 	"encoding/base64"
 	"errors"
+	"flag"
 	"fmt"
+	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/ViBiOh/flags"
 	"golang.org/x/crypto/argon2"
 )
 
@@ -30,15 +34,105 @@ var (
 	strictBase64Decoder = base64.RawStdEncoding.Strict()
 )
 
+var DefaultParams = Params{
+	Memory:      Memory,
+	Iterations:  Iterations,
+	Parallelism: Parallelism,
+	SaltLength:  SaltLength,
+	KeyLength:   KeyLength,
+}
+
+type Params struct {
+	Memory      uint32
+	Iterations  uint32
+	SaltLength  uint32
+	KeyLength   uint32
+	Parallelism uint8
+	Peppers     Peppers
+}
+
+type Config struct {
+	memory       uint
+	iterations   uint
+	parallelism  uint
+	concurrency  uint
+	queueTimeout time.Duration
+	peppers      []string
+}
+
+func Flags(fs *flag.FlagSet, prefix string, overrides ...flags.Override) *Config {
+	var config Config
+
+	flags.New("Memory", "Memory used for hashing, in KiB").Prefix(prefix).DocPrefix("argon").UintVar(fs, &config.memory, Memory, overrides)
+	flags.New("Iterations", "Number of passes over the memory").Prefix(prefix).DocPrefix("argon").UintVar(fs, &config.iterations, Iterations, overrides)
+	flags.New("Parallelism", "Number of threads used for hashing").Prefix(prefix).DocPrefix("argon").UintVar(fs, &config.parallelism, Parallelism, overrides)
+	flags.New("Concurrency", "Maximum number of concurrent hash computations, 0 for unbounded").Prefix(prefix).DocPrefix("argon").UintVar(fs, &config.concurrency, uint(runtime.NumCPU()), overrides)
+	flags.New("QueueTimeout", "Maximum wait for a hashing slot").Prefix(prefix).DocPrefix("argon").DurationVar(fs, &config.queueTimeout, time.Second*5, overrides)
+	flags.New("Peppers", "Server-side secrets mixed into passwords, in the form id:secret, first one is used for new hashes").Prefix(prefix).DocPrefix("argon").StringSliceVar(fs, &config.peppers, nil, overrides)
+
+	return &config
+}
+
+func NewParams(config *Config) (Params, error) {
+	if config.iterations < 1 || config.iterations > 1<<32-1 {
+		return Params{}, errors.New("iterations must be a positive 32 bits integer")
+	}
+
+	if config.parallelism < 1 || config.parallelism > 1<<8-1 {
+		return Params{}, errors.New("parallelism must be between 1 and 255")
+	}
+
+	if config.memory < 8*config.parallelism || config.memory > 1<<32-1 {
+		return Params{}, errors.New("memory must be at least 8 KiB per thread")
+	}
+
+	peppers, err := NewPeppers(config.peppers)
+	if err != nil {
+		return Params{}, fmt.Errorf("peppers: %w", err)
+	}
+
+	return Params{
+		Memory:      uint32(config.memory),
+		Iterations:  uint32(config.iterations),
+		Parallelism: uint8(config.parallelism),
+		SaltLength:  SaltLength,
+		KeyLength:   KeyLength,
+		Peppers:     peppers,
+	}, nil
+}
+
 func GenerateFromPassword(password string) (string, error) {
-	salt, err := salt(SaltLength)
+	return DefaultParams.GenerateFromPassword(password)
+}
+
+func (p Params) GenerateFromPassword(password string) (string, error) {
+	salt, err := salt(uint(p.SaltLength))
 	if err != nil {
 		return "", fmt.Errorf("salt: %w", err)
 	}
 
-	hash := argon2.IDKey([]byte(password), salt, Iterations, Memory, Parallelism, KeyLength)
+	peppered, err := p.Peppers.mix(p.Peppers.current, password)
+	if err != nil {
+		return "", fmt.Errorf("pepper: %w", err)
+	}
+
+	hash := argon2.IDKey(peppered, salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+
+	var keyID string
+	if len(p.Peppers.current) != 0 {
+		keyID = ",keyid=" + p.Peppers.current
+	}
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d%s$%s$%s", argon2.Version, p.Memory, p.Iterations, p.Parallelism, keyID, base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(hash)), nil
+}
+
+func (p Params) NeedsRehash(encoded string) bool {
+	memory, iterations, parallelism, keyID, salt, hash, err := parseHash(encoded)
+	if err != nil {
+		return true
+	}
 
-	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, Memory, Iterations, Parallelism, base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(hash)), nil
+	return memory != p.Memory || iterations != p.Iterations || parallelism != p.Parallelism || keyID != p.Peppers.current || uint32(len(salt)) != p.SaltLength || uint32(len(hash)) != p.KeyLength
 }
 
 func salt(length uint) ([]byte, error) {
@@ -52,12 +146,21 @@ func salt(length uint) ([]byte, error) {
 }
 
 func CompareHashAndPassword(encoded, password string) error {
-	memory, iterations, parallelism, salt, hash, err := parseHash(encoded)
+	return DefaultParams.CompareHashAndPassword(encoded, password)
+}
+
+func (p Params) CompareHashAndPassword(encoded, password string) error {
+	memory, iterations, parallelism, keyID, salt, hash, err := parseHash(encoded)
 	if err != nil {
 		return fmt.Errorf("parse: %w", err)
 	}
 
-	testedHash := argon2.IDKey([]byte(password), salt, iterations, memory, parallelism, uint32(len(hash)))
+	peppered, err := p.Peppers.mix(keyID, password)
+	if err != nil {
+		return fmt.Errorf("pepper: %w", err)
+	}
+
+	testedHash := argon2.IDKey(peppered, salt, iterations, memory, parallelism, uint32(len(hash)))
 
 	if subtle.ConstantTimeCompare(testedHash, hash) == 1 {
 		return nil
@@ -66,10 +169,11 @@ func CompareHashAndPassword(encoded, password string) error {
 	return ErrHashDontMatch
 }
 
-func parseHash(encoded string) (uint32, uint32, uint8, []byte, []byte, error) {
+func parseHash(encoded string) (uint32, uint32, uint8, string, []byte, []byte, error) {
 	var version int
 	var memory, iterations uint32
 	var parallelism uint8
+	var keyID string
 	var salt, hash []byte
 
 	var start int
@@ -86,71 +190,67 @@ func parseHash(encoded string) (uint32, uint32, uint8, []byte, []byte, error) {
 		switch partCount {
 		case 1:
 			if part != "argon2id" {
-				return 0, 0, 0, nil, nil, ErrUnhandledEncodedHash
+				return 0, 0, 0, "", nil, nil, ErrUnhandledEncodedHash
 			}
 
 		case 2:
 			if version, err = strconv.Atoi(strings.TrimPrefix(part, "v=")); err != nil {
-				return 0, 0, 0, nil, nil, fmt.Errorf("decode version `%s` : %w", part, err)
+				return 0, 0, 0, "", nil, nil, fmt.Errorf("decode version `%s` : %w", part, err)
 			}
 
 			if version != argon2.Version {
-				return 0, 0, 0, nil, nil, ErrUnhandledVersion
+				return 0, 0, 0, "", nil, nil, ErrUnhandledVersion
 			}
 
 		case 3:
-			var seen, lastEqual, lastStart int
+			var seen int
+
+			for remaining := part; len(remaining) != 0; {
+				var param string
+				param, remaining, _ = strings.Cut(remaining, ",")
+				name, value, _ := strings.Cut(param, "=")
+
+				switch name {
+				case "m":
+					bigMemory, err := strconv.ParseUint(value, 10, 32)
+					if err != nil {
+						return 0, 0, 0, "", nil, nil, fmt.Errorf("decode memory `%s`: %w", part, err)
+					}
 
-			for position := 0; position < len(part); position++ {
-				if part[position] == '=' {
-					lastEqual = position
-					continue
-				}
+					seen |= 1 << 0
+					memory = uint32(bigMemory)
 
-				if part[position] == ',' {
-					switch part[lastStart:lastEqual] {
-					case "m":
-						bigMemory, err := strconv.ParseUint(part[lastEqual+1:position], 10, 32)
-						if err != nil {
-							return 0, 0, 0, nil, nil, fmt.Errorf("decode memory `%s`: %w", part, err)
-						}
-
-						seen |= 1 << 0
-						memory = uint32(bigMemory)
-
-					case "t":
-						bigIterations, err := strconv.ParseUint(part[lastEqual+1:position], 10, 32)
-						if err != nil {
-							return 0, 0, 0, nil, nil, fmt.Errorf("decode iteration `%s`: %w", part, err)
-						}
-
-						seen |= 1 << 1
-						iterations = uint32(bigIterations)
+				case "t":
+					bigIterations, err := strconv.ParseUint(value, 10, 32)
+					if err != nil {
+						return 0, 0, 0, "", nil, nil, fmt.Errorf("decode iteration `%s`: %w", part, err)
 					}
 
-					lastStart = position + 1
-					continue
-				}
-			}
+					seen |= 1 << 1
+					iterations = uint32(bigIterations)
 
-			if part[lastStart:lastEqual] == "p" {
-				bigParallelism, err := strconv.ParseUint(part[lastEqual+1:], 10, 8)
-				if err != nil {
-					return 0, 0, 0, nil, nil, fmt.Errorf("decode parallelism `%s`: %w", part, err)
-				}
+				case "p":
+					bigParallelism, err := strconv.ParseUint(value, 10, 8)
+					if err != nil {
+						return 0, 0, 0, "", nil, nil, fmt.Errorf("decode parallelism `%s`: %w", part, err)
+					}
 
-				seen |= 1 << 2
-				parallelism = uint8(bigParallelism)
+					seen |= 1 << 2
+					parallelism = uint8(bigParallelism)
+
+				case "keyid":
+					keyID = value
+				}
 			}
 
 			if seen != 7 {
-				return 0, 0, 0, nil, nil, fmt.Errorf("decode params `%s`: %w", part, err)
+				return 0, 0, 0, "", nil, nil, fmt.Errorf("decode params `%s`: %w", part, ErrInvalidEncodedHash)
 			}
 
 		case 4:
 			salt, err = strictBase64Decoder.DecodeString(part)
 			if err != nil {
-				return 0, 0, 0, nil, nil, fmt.Errorf("decode salt: %w", err)
+				return 0, 0, 0, "", nil, nil, fmt.Errorf("decode salt: %w", err)
 			}
 		}
 
@@ -159,13 +259,13 @@ func parseHash(encoded string) (uint32, uint32, uint8, []byte, []byte, error) {
 	}
 
 	if partCount != 5 {
-		return 0, 0, 0, nil, nil, ErrInvalidEncodedHash
+		return 0, 0, 0, "", nil, nil, ErrInvalidEncodedHash
 	}
 
 	hash, err = strictBase64Decoder.DecodeString(encoded[start:])
 	if err != nil {
-		return 0, 0, 0, nil, nil, fmt.Errorf("decode hash: %w", err)
+		return 0, 0, 0, "", nil, nil, fmt.Errorf("decode hash: %w", err)
 	}
 
-	return memory, iterations, parallelism, salt, hash, nil
+	return memory, iterations, parallelism, keyID, salt, hash, nil
 }
@@ -2,6 +2,7 @@ package argon_test
 
 import (
 	"errors"
+	"flag"
 	"testing"
 
 	"github.com/ViBiOh/auth/v3/pkg/argon"
@@ -97,3 +98,92 @@ func BenchmarkCompareHashAndPassword(b *testing.B) {
 		_ = argon.CompareHashAndPassword(encodedHash, "correct horse battery staple")
 	}
 }
+
+func TestNeedsRehash(t *testing.T) {
+	t.Parallel()
+
+	current, err := argon.DefaultParams.GenerateFromPassword("correct horse battery staple")
+	assert.NoError(t, err)
+
+	weakParams := argon.DefaultParams
+	weakParams.Iterations = 1
+
+	weak, err := weakParams.GenerateFromPassword("correct horse battery staple")
+	assert.NoError(t, err)
+
+	cases := map[string]struct {
+		encoded string
+		want    bool
+	}{
+		"current": {
+			current,
+			false,
+		},
+		"weaker": {
+			weak,
+			true,
+		},
+		"bcrypt": {
+			"$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy",
+			true,
+		},
+		"invalid": {
+			"",
+			true,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, testCase.want, argon.DefaultParams.NeedsRehash(testCase.encoded))
+		})
+	}
+}
+
+func TestNewParams(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		args    []string
+		want    argon.Params
+		wantErr bool
+	}{
+		"default": {
+			nil,
+			argon.DefaultParams,
+			false,
+		},
+		"custom": {
+			[]string{"-memory", "19456", "-iterations", "2"},
+			argon.Params{Memory: 19456, Iterations: 2, Parallelism: 1, SaltLength: argon.SaltLength, KeyLength: argon.KeyLength},
+			false,
+		},
+		"no iteration": {
+			[]string{"-iterations", "0"},
+			argon.Params{},
+			true,
+		},
+		"memory too low": {
+			[]string{"-memory", "8", "-parallelism", "2"},
+			argon.Params{},
+			true,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			fs := flag.NewFlagSet(intention, flag.ContinueOnError)
+			config := argon.Flags(fs, "")
+			assert.NoError(t, fs.Parse(testCase.args))
+
+			got, gotErr := argon.NewParams(config)
+
+			assert.Equal(t, testCase.want, got)
+			assert.Equal(t, testCase.wantErr, gotErr != nil)
+		})
+	}
+}
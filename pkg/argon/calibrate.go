@@ -0,0 +1,73 @@
+package argon
+
+import (
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const calibrationSamples = 3
+
+// Calibrate finds the strongest parameters hashing under the target latency, raising memory first then iterations
+func Calibrate(target time.Duration, maxMemory uint32, parallelism uint8) (Params, time.Duration) {
+	return calibrate(target, maxMemory, parallelism, measure)
+}
+
+func calibrate(target time.Duration, maxMemory uint32, parallelism uint8, measure func(Params) time.Duration) (Params, time.Duration) {
+	best := Params{
+		Memory:      max(Memory, 8*uint32(parallelism)),
+		Iterations:  1,
+		Parallelism: parallelism,
+		SaltLength:  SaltLength,
+		KeyLength:   KeyLength,
+	}
+
+	bestDuration := measure(best)
+	if bestDuration > target {
+		return best, bestDuration
+	}
+
+	for best.Memory < maxMemory {
+		candidate := best
+		candidate.Memory = min(best.Memory*2, maxMemory)
+
+		duration := measure(candidate)
+		if duration > target {
+			break
+		}
+
+		best, bestDuration = candidate, duration
+	}
+
+	for {
+		candidate := best
+		candidate.Iterations++
+
+		duration := measure(candidate)
+		if duration > target {
+			break
+		}
+
+		best, bestDuration = candidate, duration
+	}
+
+	return best, bestDuration
+}
+
+func measure(params Params) time.Duration {
+	password := []byte("correct horse battery staple")
+	salt := make([]byte, params.SaltLength)
+
+	var fastest time.Duration
+
+	for range calibrationSamples {
+		start := time.Now()
+		argon2.IDKey(password, salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
+		if elapsed := time.Since(start); fastest == 0 || elapsed < fastest {
+			fastest = elapsed
+		}
+	}
+
+	return fastest
+}
@@ -0,0 +1,60 @@
+package argon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalibrate(t *testing.T) {
+	t.Parallel()
+
+	// one millisecond per MiB per iteration
+	linear := func(params Params) time.Duration {
+		return time.Duration(params.Memory/1024*params.Iterations) * time.Millisecond
+	}
+
+	cases := map[string]struct {
+		target      time.Duration
+		maxMemory   uint32
+		want        Params
+		wantLatency time.Duration
+	}{
+		"memory bound": {
+			time.Millisecond * 100,
+			64 * 1024,
+			Params{Memory: 64 * 1024, Iterations: 1, Parallelism: 1, SaltLength: SaltLength, KeyLength: KeyLength},
+			time.Millisecond * 64,
+		},
+		"iterations": {
+			time.Millisecond * 150,
+			32 * 1024,
+			Params{Memory: 32 * 1024, Iterations: 4, Parallelism: 1, SaltLength: SaltLength, KeyLength: KeyLength},
+			time.Millisecond * 128,
+		},
+		"latency bound": {
+			time.Millisecond * 50,
+			1024 * 1024,
+			Params{Memory: 28 * 1024, Iterations: 1, Parallelism: 1, SaltLength: SaltLength, KeyLength: KeyLength},
+			time.Millisecond * 28,
+		},
+		"too slow": {
+			time.Millisecond,
+			1024 * 1024,
+			Params{Memory: Memory, Iterations: 1, Parallelism: 1, SaltLength: SaltLength, KeyLength: KeyLength},
+			time.Millisecond * 7,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotLatency := calibrate(testCase.target, testCase.maxMemory, 1, linear)
+
+			assert.Equal(t, testCase.want, got)
+			assert.Equal(t, testCase.wantLatency, gotLatency)
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package argon
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/ViBiOh/auth/v3/pkg/model"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type Hasher struct {
+	slots    chan struct{}
+	waiting  *atomic.Int64
+	rejected metric.Int64Counter
+	Params
+	timeout time.Duration
+}
+
+var DefaultHasher = Hasher{Params: DefaultParams}
+
+func NewHasher(config *Config, meterProvider metric.MeterProvider) (Hasher, error) {
+	params, err := NewParams(config)
+	if err != nil {
+		return Hasher{}, err
+	}
+
+	hasher := Hasher{
+		Params:  params,
+		timeout: config.queueTimeout,
+	}
+
+	if config.concurrency == 0 {
+		return hasher, nil
+	}
+
+	hasher.slots = make(chan struct{}, config.concurrency)
+	hasher.waiting = new(atomic.Int64)
+
+	if meterProvider == nil {
+		return hasher, nil
+	}
+
+	meter := meterProvider.Meter("github.com/ViBiOh/auth/v3/pkg/argon")
+
+	if hasher.rejected, err = meter.Int64Counter("auth.argon.rejected", metric.WithDescription("Hash computations rejected after waiting for a slot")); err != nil {
+		return hasher, fmt.Errorf("rejected counter: %w", err)
+	}
+
+	if _, err = meter.Int64ObservableGauge("auth.argon.queue", metric.WithDescription("Hash computations waiting for a slot"), metric.WithInt64Callback(func(_ context.Context, observer metric.Int64Observer) error {
+		observer.Observe(hasher.waiting.Load())
+		return nil
+	})); err != nil {
+		return hasher, fmt.Errorf("queue gauge: %w", err)
+	}
+
+	if _, err = meter.Int64ObservableGauge("auth.argon.active", metric.WithDescription("Hash computations in progress"), metric.WithInt64Callback(func(_ context.Context, observer metric.Int64Observer) error {
+		observer.Observe(int64(len(hasher.slots)))
+		return nil
+	})); err != nil {
+		return hasher, fmt.Errorf("active gauge: %w", err)
+	}
+
+	return hasher, nil
+}
+
+func (h Hasher) GenerateFromPassword(ctx context.Context, password string) (encoded string, err error) {
+	err = h.Do(ctx, func() error {
+		encoded, err = h.Params.GenerateFromPassword(password)
+		return err
+	})
+
+	return encoded, err
+}
+
+func (h Hasher) CompareHashAndPassword(ctx context.Context, encoded, password string) error {
+	return h.Do(ctx, func() error {
+		return h.Params.CompareHashAndPassword(encoded, password)
+	})
+}
+
+func (h Hasher) Do(ctx context.Context, action func() error) error {
+	release, err := h.acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	return action()
+}
+
+func (h Hasher) acquire(ctx context.Context) (func(), error) {
+	if h.slots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case h.slots <- struct{}{}:
+		return h.release, nil
+	default:
+	}
+
+	h.waiting.Add(1)
+	defer h.waiting.Add(-1)
+
+	timer := time.NewTimer(h.timeout)
+	defer timer.Stop()
+
+	select {
+	case h.slots <- struct{}{}:
+		return h.release, nil
+
+	case <-timer.C:
+		if h.rejected != nil {
+			h.rejected.Add(ctx, 1)
+		}
+
+		return nil, fmt.Errorf("no hashing slot after %s: %w", h.timeout, model.ErrUnavailableService)
+
+	case <-ctx.Done():
+		return nil, fmt.Errorf("wait for hashing slot: %w", context.Cause(ctx))
+	}
+}
+
+func (h Hasher) release() {
+	<-h.slots
+}
@@ -0,0 +1,124 @@
+package argon
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/ViBiOh/auth/v3/pkg/model"
+)
+
+func newTestHasher(t *testing.T, args ...string) Hasher {
+	t.Helper()
+
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	config := Flags(fs, "")
+
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("parse flags: %s", err)
+	}
+
+	hasher, err := NewHasher(config, nil)
+	if err != nil {
+		t.Fatalf("new hasher: %s", err)
+	}
+
+	return hasher
+}
+
+func TestHasherQueue(t *testing.T) {
+	t.Parallel()
+
+	hasher := newTestHasher(t, "-concurrency", "1", "-queueTimeout", "50ms")
+
+	encoded, err := hasher.GenerateFromPassword(context.Background(), "secret")
+	if err != nil {
+		t.Fatalf("generate: %s", err)
+	}
+
+	release, err := hasher.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire: %s", err)
+	}
+
+	done := make(chan error)
+
+	go func() {
+		done <- hasher.CompareHashAndPassword(context.Background(), encoded, "secret")
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for hasher.waiting.Load() != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	if got := hasher.waiting.Load(); got != 1 {
+		t.Errorf("waiting = %d, want 1", got)
+	}
+
+	if err := <-done; !errors.Is(err, model.ErrUnavailableService) {
+		t.Errorf("CompareHashAndPassword() = `%v`, want `%s`", err, model.ErrUnavailableService)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := hasher.CompareHashAndPassword(ctx, encoded, "secret"); !errors.Is(err, context.Canceled) {
+		t.Errorf("CompareHashAndPassword() = `%v`, want `%s`", err, context.Canceled)
+	}
+
+	release()
+
+	if err := hasher.CompareHashAndPassword(context.Background(), encoded, "secret"); err != nil {
+		t.Errorf("CompareHashAndPassword() = `%s`", err)
+	}
+
+	if got := hasher.waiting.Load(); got != 0 {
+		t.Errorf("waiting = %d, want 0", got)
+	}
+}
+
+func TestHasherUnbounded(t *testing.T) {
+	t.Parallel()
+
+	hasher := newTestHasher(t, "-concurrency", "0")
+
+	encoded, err := hasher.GenerateFromPassword(context.Background(), "secret")
+	if err != nil {
+		t.Fatalf("generate: %s", err)
+	}
+
+	if err := hasher.CompareHashAndPassword(context.Background(), encoded, "secret"); err != nil {
+		t.Errorf("CompareHashAndPassword() = `%s`", err)
+	}
+}
+
+func TestHasherDo(t *testing.T) {
+	t.Parallel()
+
+	hasher := newTestHasher(t, "-concurrency", "1", "-queueTimeout", "10ms")
+
+	var called bool
+
+	if err := hasher.Do(context.Background(), func() error {
+		called = true
+
+		if err := hasher.Do(context.Background(), func() error { return nil }); !errors.Is(err, model.ErrUnavailableService) {
+			t.Errorf("Do() = `%v`, want `%s`", err, model.ErrUnavailableService)
+		}
+
+		return ErrHashDontMatch
+	}); !errors.Is(err, ErrHashDontMatch) {
+		t.Errorf("Do() = `%v`, want `%s`", err, ErrHashDontMatch)
+	}
+
+	if !called {
+		t.Error("Do() didn't run the action")
+	}
+
+	if got := len(hasher.slots); got != 0 {
+		t.Errorf("slots = %d, want 0", got)
+	}
+}
@@ -0,0 +1,161 @@
+package argon
+
+import (
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	maxPBKDF2Iterations = 10_000_000
+	maxScryptCost       = 1 << 14
+	maxScryptBlockSize  = 8
+	maxScryptParallel   = 5
+)
+
+// Verifier checks a password against a hash encoding other than argon2id, for upgrading it on login
+type Verifier interface {
+	Handles(encoded string) bool
+	Verify(encoded, password string) error
+}
+
+type Verifiers []Verifier
+
+var (
+	BcryptVerifier Verifier = bcryptVerifier{}
+	PBKDF2Verifier Verifier = pbkdf2Verifier{}
+	ScryptVerifier Verifier = scryptVerifier{}
+	SSHAVerifier   Verifier = sshaVerifier{}
+
+	LegacyVerifiers = Verifiers{BcryptVerifier, PBKDF2Verifier, ScryptVerifier, SSHAVerifier}
+)
+
+func (v Verifiers) Verify(encoded, password string) error {
+	for _, verifier := range v {
+		if verifier.Handles(encoded) {
+			return verifier.Verify(encoded, password)
+		}
+	}
+
+	return ErrUnhandledEncodedHash
+}
+
+type bcryptVerifier struct{}
+
+func (bcryptVerifier) Handles(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+func (bcryptVerifier) Verify(encoded, password string) error {
+	if bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password)) != nil {
+		return ErrHashDontMatch
+	}
+
+	return nil
+}
+
+// pbkdf2Verifier handles the Django format: pbkdf2_sha256$<iterations>$<salt>$<base64 hash>
+type pbkdf2Verifier struct{}
+
+func (pbkdf2Verifier) Handles(encoded string) bool {
+	return strings.HasPrefix(encoded, "pbkdf2_sha256$")
+}
+
+func (pbkdf2Verifier) Verify(encoded, password string) error {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 {
+		return ErrInvalidEncodedHash
+	}
+
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations < 1 || iterations > maxPBKDF2Iterations {
+		return fmt.Errorf("decode iterations `%s`: %w", parts[1], ErrInvalidEncodedHash)
+	}
+
+	hash, err := base64.StdEncoding.DecodeString(parts[3])
+	if err != nil || len(hash) == 0 {
+		return fmt.Errorf("decode hash: %w", ErrInvalidEncodedHash)
+	}
+
+	testedHash, err := pbkdf2.Key(sha256.New, password, []byte(parts[2]), iterations, len(hash))
+	if err != nil {
+		return fmt.Errorf("pbkdf2: %w", err)
+	}
+
+	return compare(testedHash, hash)
+}
+
+// scryptVerifier handles the Django format: scrypt$<cost>$<salt>$<block size>$<parallelism>$<base64 hash>
+// Parameters above the ones Django produces are rejected, the memory cost being 128 * cost * block size
+type scryptVerifier struct{}
+
+func (scryptVerifier) Handles(encoded string) bool {
+	return strings.HasPrefix(encoded, "scrypt$")
+}
+
+func (scryptVerifier) Verify(encoded, password string) error {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return ErrInvalidEncodedHash
+	}
+
+	cost, costErr := strconv.Atoi(parts[1])
+	blockSize, blockErr := strconv.Atoi(parts[3])
+	parallelism, parallelErr := strconv.Atoi(parts[4])
+
+	if err := errors.Join(costErr, blockErr, parallelErr); err != nil {
+		return fmt.Errorf("decode params: %w", errors.Join(ErrInvalidEncodedHash, err))
+	}
+
+	if cost < 2 || cost > maxScryptCost || blockSize < 1 || blockSize > maxScryptBlockSize || parallelism < 1 || parallelism > maxScryptParallel {
+		return fmt.Errorf("params out of range: %w", ErrInvalidEncodedHash)
+	}
+
+	hash, err := base64.StdEncoding.DecodeString(parts[5])
+	if err != nil || len(hash) == 0 {
+		return fmt.Errorf("decode hash: %w", ErrInvalidEncodedHash)
+	}
+
+	testedHash, err := scrypt.Key([]byte(password), []byte(parts[2]), cost, blockSize, parallelism, len(hash))
+	if err != nil {
+		return fmt.Errorf("scrypt: %w", err)
+	}
+
+	return compare(testedHash, hash)
+}
+
+// sshaVerifier handles the LDAP format: {SSHA}<base64 of sha1(password + salt) + salt>
+type sshaVerifier struct{}
+
+func (sshaVerifier) Handles(encoded string) bool {
+	return strings.HasPrefix(encoded, "{SSHA}")
+}
+
+func (sshaVerifier) Verify(encoded, password string) error {
+	payload, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(encoded, "{SSHA}"))
+	if err != nil || len(payload) <= sha1.Size {
+		return fmt.Errorf("decode hash: %w", ErrInvalidEncodedHash)
+	}
+
+	hash, salt := payload[:sha1.Size], payload[sha1.Size:]
+	testedHash := sha1.Sum(append([]byte(password), salt...))
+
+	return compare(testedHash[:], hash)
+}
+
+func compare(tested, expected []byte) error {
+	if subtle.ConstantTimeCompare(tested, expected) == 1 {
+		return nil
+	}
+
+	return ErrHashDontMatch
+}
@@ -0,0 +1,96 @@
+package argon_test
+
+import (
+	"testing"
+
+	"github.com/ViBiOh/auth/v3/pkg/argon"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestLegacyVerifiers(t *testing.T) {
+	t.Parallel()
+
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	cases := map[string]struct {
+		encoded  string
+		password string
+		wantErr  error
+	}{
+		"bcrypt": {
+			string(bcryptHash),
+			"correct horse",
+			nil,
+		},
+		"bcrypt mismatch": {
+			string(bcryptHash),
+			"battery staple",
+			argon.ErrHashDontMatch,
+		},
+		"pbkdf2": {
+			"pbkdf2_sha256$1000$saltsalt$qQDPSZa3Ormyy9oK1Pu0ZLLwP2Svzmx3yu8OvDdq/J0=",
+			"correct horse",
+			nil,
+		},
+		"pbkdf2 mismatch": {
+			"pbkdf2_sha256$1000$saltsalt$qQDPSZa3Ormyy9oK1Pu0ZLLwP2Svzmx3yu8OvDdq/J0=",
+			"battery staple",
+			argon.ErrHashDontMatch,
+		},
+		"pbkdf2 invalid iterations": {
+			"pbkdf2_sha256$0$saltsalt$qQDPSZa3Ormyy9oK1Pu0ZLLwP2Svzmx3yu8OvDdq/J0=",
+			"correct horse",
+			argon.ErrInvalidEncodedHash,
+		},
+		"scrypt": {
+			"scrypt$1024$saltsalt$8$1$ZGeSwropSvEgLQU1pjltINKrRvmEBSnohdqYXUe5EZ8lX839he8Xlnu1Hm6vBg8U0dik5GHfzXu1ax2apIYCXQ==",
+			"correct horse",
+			nil,
+		},
+		"scrypt mismatch": {
+			"scrypt$1024$saltsalt$8$1$ZGeSwropSvEgLQU1pjltINKrRvmEBSnohdqYXUe5EZ8lX839he8Xlnu1Hm6vBg8U0dik5GHfzXu1ax2apIYCXQ==",
+			"battery staple",
+			argon.ErrHashDontMatch,
+		},
+		"scrypt cost too high": {
+			"scrypt$32768$saltsalt$8$1$ZGeSwropSvEgLQU1pjltINKrRvmEBSnohdqYXUe5EZ8lX839he8Xlnu1Hm6vBg8U0dik5GHfzXu1ax2apIYCXQ==",
+			"correct horse",
+			argon.ErrInvalidEncodedHash,
+		},
+		"scrypt block size too high": {
+			"scrypt$1024$saltsalt$32$1$ZGeSwropSvEgLQU1pjltINKrRvmEBSnohdqYXUe5EZ8lX839he8Xlnu1Hm6vBg8U0dik5GHfzXu1ax2apIYCXQ==",
+			"correct horse",
+			argon.ErrInvalidEncodedHash,
+		},
+		"scrypt parallelism too high": {
+			"scrypt$1024$saltsalt$8$6$ZGeSwropSvEgLQU1pjltINKrRvmEBSnohdqYXUe5EZ8lX839he8Xlnu1Hm6vBg8U0dik5GHfzXu1ax2apIYCXQ==",
+			"correct horse",
+			argon.ErrInvalidEncodedHash,
+		},
+		"ssha": {
+			"{SSHA}5pcxo13YdJml98eQSIlT3FMEBMMBAgME",
+			"correct horse",
+			nil,
+		},
+		"ssha mismatch": {
+			"{SSHA}5pcxo13YdJml98eQSIlT3FMEBMMBAgME",
+			"battery staple",
+			argon.ErrHashDontMatch,
+		},
+		"unhandled": {
+			"{MD5}fdNJjDJbz5jTzLSBNqyHEQ==",
+			"correct horse",
+			argon.ErrUnhandledEncodedHash,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			assert.ErrorIs(t, argon.LegacyVerifiers.Verify(testCase.encoded, testCase.password), testCase.wantErr)
+		})
+	}
+}
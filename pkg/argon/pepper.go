@@ -0,0 +1,80 @@
+package argon
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const minPepperLength = 16
+
+var ErrUnknownPepper = errors.New("unknown pepper")
+
+type Peppers struct {
+	keys    map[string][]byte
+	current string
+}
+
+func NewPeppers(values []string) (Peppers, error) {
+	var peppers Peppers
+
+	for _, value := range values {
+		id, secret, ok := strings.Cut(value, ":")
+		if !ok {
+			return Peppers{}, errors.New("pepper must be in the form `id:secret`")
+		}
+
+		if !validPepperID(id) {
+			return Peppers{}, fmt.Errorf("pepper id `%s` must be alphanumeric", id)
+		}
+
+		if len(secret) < minPepperLength {
+			return Peppers{}, fmt.Errorf("pepper `%s` must be at least %d bytes", id, minPepperLength)
+		}
+
+		if peppers.keys == nil {
+			peppers.keys = make(map[string][]byte)
+			peppers.current = id
+		}
+
+		if _, ok := peppers.keys[id]; ok {
+			return Peppers{}, fmt.Errorf("pepper `%s` is declared twice", id)
+		}
+
+		peppers.keys[id] = []byte(secret)
+	}
+
+	return peppers, nil
+}
+
+func validPepperID(id string) bool {
+	if len(id) == 0 {
+		return false
+	}
+
+	for _, char := range id {
+		if (char < 'a' || char > 'z') && (char < 'A' || char > 'Z') && (char < '0' || char > '9') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p Peppers) mix(keyID, password string) ([]byte, error) {
+	if len(keyID) == 0 {
+		return []byte(password), nil
+	}
+
+	key, ok := p.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("key `%s`: %w", keyID, ErrUnknownPepper)
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(password))
+
+	return mac.Sum(nil), nil
+}
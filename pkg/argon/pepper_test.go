@@ -0,0 +1,154 @@
+package argon_test
+
+import (
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/ViBiOh/auth/v3/pkg/argon"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPepperedParams(t *testing.T, peppers ...string) argon.Params {
+	t.Helper()
+
+	var args []string
+	for _, pepper := range peppers {
+		args = append(args, "-peppers", pepper)
+	}
+
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	config := argon.Flags(fs, "")
+	assert.NoError(t, fs.Parse(args))
+
+	params, err := argon.NewParams(config)
+	assert.NoError(t, err)
+
+	return params
+}
+
+func TestPepper(t *testing.T) {
+	t.Parallel()
+
+	password := "correct horse battery staple"
+
+	initial := newPepperedParams(t, "k1:a-long-enough-secret")
+	rotated := newPepperedParams(t, "k2:another-long-secret", "k1:a-long-enough-secret")
+
+	unpeppered, err := argon.DefaultParams.GenerateFromPassword(password)
+	assert.NoError(t, err)
+
+	peppered, err := initial.GenerateFromPassword(password)
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(peppered, ",keyid=k1$"))
+
+	cases := map[string]struct {
+		params      argon.Params
+		encoded     string
+		password    string
+		wantErr     error
+		wantRehash  bool
+		wantSuccess bool
+	}{
+		"peppered": {
+			initial,
+			peppered,
+			password,
+			nil,
+			false,
+			true,
+		},
+		"wrong password": {
+			initial,
+			peppered,
+			"incorrect",
+			argon.ErrHashDontMatch,
+			false,
+			false,
+		},
+		"missing pepper": {
+			argon.DefaultParams,
+			peppered,
+			password,
+			argon.ErrUnknownPepper,
+			true,
+			false,
+		},
+		"rotated": {
+			rotated,
+			peppered,
+			password,
+			nil,
+			true,
+			true,
+		},
+		"not peppered yet": {
+			initial,
+			unpeppered,
+			password,
+			nil,
+			true,
+			true,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			gotErr := testCase.params.CompareHashAndPassword(testCase.encoded, testCase.password)
+
+			if testCase.wantSuccess {
+				assert.NoError(t, gotErr)
+			} else {
+				assert.ErrorIs(t, gotErr, testCase.wantErr)
+			}
+
+			assert.Equal(t, testCase.wantRehash, testCase.params.NeedsRehash(testCase.encoded))
+		})
+	}
+}
+
+func TestNewPeppers(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		values  []string
+		wantErr bool
+	}{
+		"empty": {
+			nil,
+			false,
+		},
+		"valid": {
+			[]string{"k2:another-long-secret", "k1:a-long-enough-secret"},
+			false,
+		},
+		"no id": {
+			[]string{"a-long-enough-secret"},
+			true,
+		},
+		"invalid id": {
+			[]string{"k$1:a-long-enough-secret"},
+			true,
+		},
+		"short secret": {
+			[]string{"k1:secret"},
+			true,
+		},
+		"duplicate": {
+			[]string{"k1:a-long-enough-secret", "k1:another-long-secret"},
+			true,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			_, gotErr := argon.NewPeppers(testCase.values)
+
+			assert.Equal(t, testCase.wantErr, gotErr != nil)
+		})
+	}
+}
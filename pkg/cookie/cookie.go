@@ -31,8 +31,10 @@ type Claim[T ClaimUser] struct {
 }
 
 type Service[T ClaimUser] struct {
+	audience      string
 	hmacSecret    []byte
 	jwtExpiration time.Duration
+	sameSite      http.SameSite
 	devMode       bool
 }
 
@@ -54,10 +56,21 @@ func New[T ClaimUser](config *Config) Service[T] {
 	return Service[T]{
 		hmacSecret:    []byte(config.hmacSecret),
 		jwtExpiration: config.jwtExpiration,
+		sameSite:      http.SameSiteStrictMode,
 		devMode:       os.Getenv("ENV") == "dev",
 	}
 }
 
+func Derive[U, T ClaimUser](service Service[T], audience string, expiration time.Duration, sameSite http.SameSite) Service[U] {
+	return Service[U]{
+		audience:      audience,
+		hmacSecret:    service.hmacSecret,
+		jwtExpiration: expiration,
+		sameSite:      sameSite,
+		devMode:       service.devMode,
+	}
+}
+
 func (s Service[T]) IsEnabled() bool {
 	return len(s.hmacSecret) != 0
 }
@@ -78,6 +91,10 @@ func (s Service[T]) Get(r *http.Request, name string) (Claim[T], error) {
 		return claim, fmt.Errorf("parse JWT: %w", err)
 	}
 
+	if !s.isAudience(claim.Audience) {
+		return claim, fmt.Errorf("parse JWT: %w", jwt.ErrTokenInvalidAudience)
+	}
+
 	return claim, nil
 }
 
@@ -102,10 +119,18 @@ func (s Service[T]) Clear(w http.ResponseWriter, name string) {
 		Path:     "/",
 		Secure:   !s.devMode,
 		HttpOnly: true,
-		SameSite: http.SameSiteStrictMode,
+		SameSite: s.sameSite,
 	})
 }
 
+func (s Service[T]) isAudience(audience jwt.ClaimStrings) bool {
+	if len(s.audience) == 0 {
+		return len(audience) == 0
+	}
+
+	return len(audience) == 1 && audience[0] == s.audience
+}
+
 func (s Service[T]) jwtKeyFunc(_ *jwt.Token) (any, error) {
 	return s.hmacSecret, nil
 }
@@ -113,8 +138,14 @@ func (s Service[T]) jwtKeyFunc(_ *jwt.Token) (any, error) {
 func (s Service[T]) newClaim(content T) Claim[T] {
 	now := time.Now()
 
+	var audience jwt.ClaimStrings
+	if len(s.audience) != 0 {
+		audience = jwt.ClaimStrings{s.audience}
+	}
+
 	return Claim[T]{
 		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  audience,
 			ID:        id.New(),
 			Subject:   content.GetSubject(),
 			ExpiresAt: jwt.NewNumericDate(now.Add(s.jwtExpiration)),
@@ -134,6 +165,6 @@ func (s Service[T]) setCookie(w http.ResponseWriter, name, value string) {
 		Path:     "/",
 		Secure:   !s.devMode,
 		HttpOnly: true,
-		SameSite: http.SameSiteStrictMode,
+		SameSite: s.sameSite,
 	})
 }
@@ -0,0 +1,383 @@
+package invite
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ViBiOh/auth/v3/pkg/mailer"
+	"github.com/ViBiOh/auth/v3/pkg/model"
+	"github.com/ViBiOh/flags"
+	"github.com/ViBiOh/httputils/v4/pkg/httperror"
+	"github.com/ViBiOh/httputils/v4/pkg/httpjson"
+)
+
+const (
+	deliverySent   = "sent"
+	deliveryFailed = "failed"
+)
+
+type Storage interface {
+	List(ctx context.Context, ids ...string) ([]model.User, error)
+	IsAuthorized(ctx context.Context, user model.User, profile string) bool
+
+	CreateInvite(ctx context.Context, invitation model.Invitation) (model.Invitation, error)
+	GetInvitation(ctx context.Context, userID string) (model.Invitation, error)
+	ListInvitations(ctx context.Context) ([]model.Invitation, error)
+	RegenerateInvite(ctx context.Context, invitation model.Invitation) (model.Invitation, error)
+	RevokeInvite(ctx context.Context, invitation model.Invitation) error
+	UpdateInviteDelivery(ctx context.Context, invitation model.Invitation) error
+}
+
+type Provider struct {
+	Name         string
+	RegisterPath string
+}
+
+type Config struct {
+	publicURL string
+	profile   string
+}
+
+type Service struct {
+	storage   Storage
+	mailer    mailer.Mailer
+	template  mailer.Template
+	publicURL string
+	profile   string
+	providers []Provider
+}
+
+type Option func(Service) Service
+
+func WithMailer(instance mailer.Mailer, renderer mailer.Renderer) Option {
+	return func(service Service) Service {
+		service.mailer = instance
+		service.template = mailer.NewTemplate(renderer, "invite-email")
+
+		return service
+	}
+}
+
+type Invitation struct {
+	model.Invitation
+	Status        string            `json:"status"`
+	CreatorName   string            `json:"creator_name,omitempty"`
+	Registrations map[string]string `json:"registrations,omitempty"`
+}
+
+type createRequest struct {
+	Expiration  time.Time `json:"expiration"`
+	Description string    `json:"description"`
+	Provider    string    `json:"provider"`
+	Email       string    `json:"email"`
+	Profiles    []string  `json:"profiles"`
+	MaxUses     int       `json:"max_uses"`
+}
+
+func Flags(fs *flag.FlagSet, prefix string, overrides ...flags.Override) *Config {
+	var config Config
+
+	flags.New("PublicURL", "Public URL used for registration links").Prefix(prefix).DocPrefix("invite").StringVar(fs, &config.publicURL, "http://127.0.0.1:1080", overrides)
+	flags.New("Profile", "Profile required to manage invites").Prefix(prefix).DocPrefix("invite").StringVar(fs, &config.profile, "admin", overrides)
+
+	return &config
+}
+
+func New(config *Config, storage Storage, providers []Provider, options ...Option) Service {
+	service := Service{
+		storage:   storage,
+		publicURL: strings.TrimSuffix(config.publicURL, "/"),
+		profile:   config.profile,
+		providers: providers,
+	}
+
+	for _, option := range options {
+		service = option(service)
+	}
+
+	return service
+}
+
+func (s Service) Mux(prefix string, mux *http.ServeMux) {
+	mux.Handle("GET "+prefix, s.admin(s.List))
+	mux.Handle("POST "+prefix, s.admin(s.Create))
+	mux.Handle("DELETE "+prefix+"/{id}", s.admin(s.Revoke))
+	mux.Handle("POST "+prefix+"/{id}/regenerate", s.admin(s.Regenerate))
+	mux.Handle("POST "+prefix+"/{id}/send", s.admin(s.Send))
+}
+
+func (s Service) admin(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		user := model.ReadUser(ctx)
+		if len(user.ID) == 0 {
+			httperror.Unauthorized(ctx, w, errors.New("login is required"))
+			return
+		}
+
+		if !s.storage.IsAuthorized(ctx, user, s.profile) {
+			httperror.Forbidden(ctx, w)
+			return
+		}
+
+		next(w, r)
+	})
+}
+
+func (s Service) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	invitations, err := s.storage.ListInvitations(ctx)
+	if err != nil {
+		httperror.InternalServerError(ctx, w, fmt.Errorf("list: %w", err))
+		return
+	}
+
+	creators := s.creatorNames(ctx, invitations)
+	now := time.Now()
+
+	output := make([]Invitation, 0, len(invitations))
+	for _, invitation := range invitations {
+		item := s.toInvitation(invitation, now)
+		item.CreatorName = creators[invitation.Creator]
+
+		output = append(output, item)
+	}
+
+	httpjson.Write(ctx, w, http.StatusOK, output)
+}
+
+func (s Service) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	payload, err := httpjson.Parse[createRequest](r)
+	if err != nil {
+		httperror.BadRequest(ctx, w, err)
+		return
+	}
+
+	payload, err = s.validate(payload)
+	if err != nil {
+		httperror.BadRequest(ctx, w, err)
+		return
+	}
+
+	invitation, err := s.storage.CreateInvite(ctx, model.Invitation{
+		Description: payload.Description,
+		Expiration:  payload.Expiration,
+		MaxUses:     payload.MaxUses,
+		Provider:    payload.Provider,
+		Profiles:    payload.Profiles,
+		Email:       payload.Email,
+		Creator:     model.ReadUser(ctx).ID,
+	})
+	if err != nil {
+		httperror.InternalServerError(ctx, w, fmt.Errorf("create: %w", err))
+		return
+	}
+
+	if len(invitation.Email) != 0 {
+		invitation = s.deliver(ctx, invitation)
+	}
+
+	httpjson.Write(ctx, w, http.StatusCreated, s.toInvitation(invitation, time.Now()))
+}
+
+func (s Service) Send(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if s.mailer == nil {
+		httperror.BadRequest(ctx, w, errors.New("mailer is not configured"))
+		return
+	}
+
+	invitation, ok := s.getInvitation(w, r)
+	if !ok {
+		return
+	}
+
+	if email := r.URL.Query().Get("email"); len(email) != 0 {
+		address, err := mail.ParseAddress(email)
+		if err != nil {
+			httperror.BadRequest(ctx, w, fmt.Errorf("invalid email: %w", err))
+			return
+		}
+
+		invitation.Email = address.Address
+	}
+
+	if len(invitation.Email) == 0 {
+		httperror.BadRequest(ctx, w, errors.New("email is required"))
+		return
+	}
+
+	if invitation.Status(time.Now()) != "active" {
+		httperror.BadRequest(ctx, w, errors.New("invite is not active"))
+		return
+	}
+
+	httpjson.Write(ctx, w, http.StatusOK, s.toInvitation(s.deliver(ctx, invitation), time.Now()))
+}
+
+func (s Service) deliver(ctx context.Context, invitation model.Invitation) model.Invitation {
+	invitation.Delivery = deliverySent
+
+	email, err := s.template.Email(s.toInvitation(invitation, time.Now()), invitation.Email)
+	if err == nil {
+		err = s.mailer.Send(ctx, email)
+	}
+
+	if err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "unable to send invite", slog.String("id", invitation.UserID), slog.Any("error", err))
+		invitation.Delivery = deliveryFailed
+	}
+
+	if err := s.storage.UpdateInviteDelivery(ctx, invitation); err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "unable to update invite delivery", slog.String("id", invitation.UserID), slog.Any("error", err))
+	}
+
+	return invitation
+}
+
+func (s Service) Revoke(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	invitation, ok := s.getInvitation(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.storage.RevokeInvite(ctx, invitation); err != nil {
+		httperror.InternalServerError(ctx, w, fmt.Errorf("revoke: %w", err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s Service) Regenerate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	invitation, ok := s.getInvitation(w, r)
+	if !ok {
+		return
+	}
+
+	invitation, err := s.storage.RegenerateInvite(ctx, invitation)
+	if err != nil {
+		httperror.InternalServerError(ctx, w, fmt.Errorf("regenerate: %w", err))
+		return
+	}
+
+	httpjson.Write(ctx, w, http.StatusOK, s.toInvitation(invitation, time.Now()))
+}
+
+func (s Service) getInvitation(w http.ResponseWriter, r *http.Request) (model.Invitation, bool) {
+	ctx := r.Context()
+
+	invitation, err := s.storage.GetInvitation(ctx, r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, model.ErrUnknownUser) {
+			httperror.NotFound(ctx, w, errors.New("unknown invite"))
+			return invitation, false
+		}
+
+		httperror.InternalServerError(ctx, w, fmt.Errorf("get: %w", err))
+		return invitation, false
+	}
+
+	return invitation, true
+}
+
+func (s Service) validate(payload createRequest) (createRequest, error) {
+	if len(strings.TrimSpace(payload.Description)) == 0 {
+		return payload, errors.New("description is required")
+	}
+
+	if len(payload.Email) != 0 {
+		if s.mailer == nil {
+			return payload, errors.New("mailer is not configured")
+		}
+
+		address, err := mail.ParseAddress(payload.Email)
+		if err != nil {
+			return payload, fmt.Errorf("invalid email: %w", err)
+		}
+
+		payload.Email = address.Address
+	}
+
+	if payload.MaxUses < 0 {
+		return payload, errors.New("max_uses must be positive")
+	}
+
+	if !payload.Expiration.IsZero() && !payload.Expiration.After(time.Now()) {
+		return payload, errors.New("expiration must be in the future")
+	}
+
+	if len(payload.Provider) != 0 && !slices.ContainsFunc(s.providers, func(provider Provider) bool {
+		return provider.Name == payload.Provider
+	}) {
+		return payload, fmt.Errorf("unknown provider `%s`", payload.Provider)
+	}
+
+	return payload, nil
+}
+
+func (s Service) toInvitation(invitation model.Invitation, now time.Time) Invitation {
+	output := Invitation{
+		Invitation: invitation,
+		Status:     invitation.Status(now),
+	}
+
+	if output.Status != "active" {
+		return output
+	}
+
+	output.Registrations = make(map[string]string, len(s.providers))
+
+	for _, provider := range s.providers {
+		if invitation.AllowProvider(provider.Name) {
+			output.Registrations[provider.Name] = s.publicURL + provider.RegisterPath + "?registration=" + url.QueryEscape(invitation.Token)
+		}
+	}
+
+	return output
+}
+
+func (s Service) creatorNames(ctx context.Context, invitations []model.Invitation) map[string]string {
+	var ids []string
+
+	for _, invitation := range invitations {
+		if len(invitation.Creator) != 0 && !slices.Contains(ids, invitation.Creator) {
+			ids = append(ids, invitation.Creator)
+		}
+	}
+
+	output := make(map[string]string, len(ids))
+	if len(ids) == 0 {
+		return output
+	}
+
+	users, err := s.storage.List(ctx, ids...)
+	if err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "unable to list creators", slog.Any("error", err))
+		return output
+	}
+
+	for _, user := range users {
+		output[user.ID] = user.Name
+	}
+
+	return output
+}
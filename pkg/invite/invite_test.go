@@ -0,0 +1,232 @@
+package invite
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ViBiOh/auth/v3/internal/fake"
+	"github.com/ViBiOh/auth/v3/pkg/model"
+)
+
+type testStorage struct {
+	invitations map[string]model.Invitation
+}
+
+func (ts testStorage) List(_ context.Context, _ ...string) ([]model.User, error) {
+	return []model.User{{ID: "admin", Name: "Admin"}}, nil
+}
+
+func (ts testStorage) IsAuthorized(_ context.Context, user model.User, profile string) bool {
+	return user.ID == "admin" && profile == "admin"
+}
+
+func (ts testStorage) CreateInvite(_ context.Context, invitation model.Invitation) (model.Invitation, error) {
+	invitation.UserID = "new"
+	invitation.Token = "token"
+	invitation.MaxUses = max(invitation.MaxUses, 1)
+
+	return invitation, nil
+}
+
+func (ts testStorage) GetInvitation(_ context.Context, userID string) (model.Invitation, error) {
+	invitation, ok := ts.invitations[userID]
+	if !ok {
+		return invitation, model.ErrUnknownUser
+	}
+
+	return invitation, nil
+}
+
+func (ts testStorage) ListInvitations(_ context.Context) ([]model.Invitation, error) {
+	var output []model.Invitation
+
+	for _, invitation := range ts.invitations {
+		output = append(output, invitation)
+	}
+
+	return output, nil
+}
+
+func (ts testStorage) RegenerateInvite(_ context.Context, invitation model.Invitation) (model.Invitation, error) {
+	invitation.Token = "regenerated"
+
+	return invitation, nil
+}
+
+func (ts testStorage) RevokeInvite(_ context.Context, _ model.Invitation) error {
+	return nil
+}
+
+func (ts testStorage) UpdateInviteDelivery(_ context.Context, _ model.Invitation) error {
+	return nil
+}
+
+const emailTemplate = `
+{{ define "invite-email-subject" }}Invite{{ end }}
+{{ define "invite-email-text" }}{{ range .Registrations }}{{ . }}{{ end }}{{ end }}
+{{ define "invite-email-html" }}{{ range .Registrations }}<a href="{{ . }}"></a>{{ end }}{{ end }}
+`
+
+func TestMux(t *testing.T) {
+	t.Parallel()
+
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	config := Flags(fs, "")
+
+	if err := fs.Parse([]string{"-publicURL", "https://auth.vibioh.fr/"}); err != nil {
+		t.Fatalf("parse flags: %s", err)
+	}
+
+	sender := fake.NewMailer(nil)
+
+	instance := New(config, testStorage{invitations: map[string]model.Invitation{
+		"1": {UserID: "1", Token: "secret", Description: "team", MaxUses: 5, Uses: 1, Provider: "github", Creator: "admin"},
+	}}, []Provider{{Name: "github", RegisterPath: "/oauth/github/register"}, {Name: "google", RegisterPath: "/oauth/google/register"}}, WithMailer(sender, fake.NewRenderer(emailTemplate)))
+
+	mux := http.NewServeMux()
+	instance.Mux("/invites", mux)
+
+	cases := map[string]struct {
+		user       model.User
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		"anonymous": {
+			model.User{},
+			http.MethodGet,
+			"/invites",
+			"",
+			http.StatusUnauthorized,
+			"",
+		},
+		"not admin": {
+			model.User{ID: "2"},
+			http.MethodGet,
+			"/invites",
+			"",
+			http.StatusForbidden,
+			"",
+		},
+		"list": {
+			model.User{ID: "admin"},
+			http.MethodGet,
+			"/invites",
+			"",
+			http.StatusOK,
+			`"creator_name":"Admin","registrations":{"github":"https://auth.vibioh.fr/oauth/github/register?registration=secret"}`,
+		},
+		"create": {
+			model.User{ID: "admin"},
+			http.MethodPost,
+			"/invites",
+			`{"description":"bob"}`,
+			http.StatusCreated,
+			`"registrations":{"github":"https://auth.vibioh.fr/oauth/github/register?registration=token","google":"https://auth.vibioh.fr/oauth/google/register?registration=token"}`,
+		},
+		"create with email": {
+			model.User{ID: "admin"},
+			http.MethodPost,
+			"/invites",
+			`{"description":"bob","email":"bob@vibioh.fr"}`,
+			http.StatusCreated,
+			`"email":"bob@vibioh.fr","delivery":"sent"`,
+		},
+		"create with display name": {
+			model.User{ID: "admin"},
+			http.MethodPost,
+			"/invites",
+			`{"description":"bob","email":"Bob <bob@vibioh.fr>"}`,
+			http.StatusCreated,
+			`"email":"bob@vibioh.fr","delivery":"sent"`,
+		},
+		"create invalid email": {
+			model.User{ID: "admin"},
+			http.MethodPost,
+			"/invites",
+			`{"description":"bob","email":"bob"}`,
+			http.StatusBadRequest,
+			"",
+		},
+		"send without email": {
+			model.User{ID: "admin"},
+			http.MethodPost,
+			"/invites/1/send",
+			"",
+			http.StatusBadRequest,
+			"",
+		},
+		"create unknown provider": {
+			model.User{ID: "admin"},
+			http.MethodPost,
+			"/invites",
+			`{"description":"bob","provider":"discord"}`,
+			http.StatusBadRequest,
+			"",
+		},
+		"regenerate": {
+			model.User{ID: "admin"},
+			http.MethodPost,
+			"/invites/1/regenerate",
+			"",
+			http.StatusOK,
+			`registration=regenerated`,
+		},
+		"revoke unknown": {
+			model.User{ID: "admin"},
+			http.MethodDelete,
+			"/invites/2",
+			"",
+			http.StatusNotFound,
+			"",
+		},
+		"revoke": {
+			model.User{ID: "admin"},
+			http.MethodDelete,
+			"/invites/1",
+			"",
+			http.StatusNoContent,
+			"",
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(testCase.method, testCase.path, strings.NewReader(testCase.body))
+			req = req.WithContext(model.StoreUser(req.Context(), testCase.user))
+
+			writer := httptest.NewRecorder()
+			mux.ServeHTTP(writer, req)
+
+			if got := writer.Code; got != testCase.wantStatus {
+				t.Errorf("Mux() = %d, want %d", got, testCase.wantStatus)
+			}
+
+			if got := writer.Body.String(); !strings.Contains(got, testCase.wantBody) {
+				t.Errorf("Mux() = `%s`, want `%s`", got, testCase.wantBody)
+			}
+
+			if testCase.wantStatus == http.StatusOK && !json.Valid(writer.Body.Bytes()) {
+				t.Errorf("Mux() = `%s`, want valid JSON", writer.Body.String())
+			}
+
+			if strings.HasPrefix(intention, "create with") {
+				for _, email := range sender.Emails() {
+					if !strings.Contains(email.Text, "registration=token") || !slices.Equal(email.To, []string{"bob@vibioh.fr"}) {
+						t.Errorf("Mux() sent %+v, want a registration link to bob@vibioh.fr", email)
+					}
+				}
+			}
+		})
+	}
+}
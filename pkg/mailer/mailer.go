@@ -0,0 +1,226 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ViBiOh/flags"
+)
+
+type Email struct {
+	Subject string
+	HTML    string
+	Text    string
+	To      []string
+}
+
+var ErrNoTLS = errors.New("server doesn't support STARTTLS")
+
+type Mailer interface {
+	Send(ctx context.Context, email Email) error
+}
+
+type Config struct {
+	host     string
+	username string
+	password string
+	from     string
+	port     uint
+	timeout  time.Duration
+	insecure bool
+}
+
+type SMTP struct {
+	host        string
+	address     string
+	from        string
+	username    string
+	password    string
+	timeout     time.Duration
+	implicitTLS bool
+	insecure    bool
+}
+
+var _ Mailer = SMTP{}
+
+func Flags(fs *flag.FlagSet, prefix string, overrides ...flags.Override) *Config {
+	var config Config
+
+	flags.New("Host", "SMTP host, mailing is disabled if empty").Prefix(prefix).DocPrefix("mailer").StringVar(fs, &config.host, "", overrides)
+	flags.New("Port", "SMTP port, TLS is implicit on 465").Prefix(prefix).DocPrefix("mailer").UintVar(fs, &config.port, 587, overrides)
+	flags.New("Username", "SMTP username").Prefix(prefix).DocPrefix("mailer").StringVar(fs, &config.username, "", overrides)
+	flags.New("Password", "SMTP password").Prefix(prefix).DocPrefix("mailer").StringVar(fs, &config.password, "", overrides)
+	flags.New("From", "Sender address").Prefix(prefix).DocPrefix("mailer").StringVar(fs, &config.from, "auth@localhost", overrides)
+	flags.New("Timeout", "SMTP timeout").Prefix(prefix).DocPrefix("mailer").DurationVar(fs, &config.timeout, time.Second*10, overrides)
+	flags.New("Insecure", "Send in cleartext when the server doesn't support STARTTLS").Prefix(prefix).DocPrefix("mailer").BoolVar(fs, &config.insecure, false, overrides)
+
+	return &config
+}
+
+func New(config *Config) *SMTP {
+	if len(config.host) == 0 {
+		return nil
+	}
+
+	return &SMTP{
+		host:        config.host,
+		address:     net.JoinHostPort(config.host, strconv.FormatUint(uint64(config.port), 10)),
+		from:        config.from,
+		username:    config.username,
+		password:    config.password,
+		timeout:     config.timeout,
+		implicitTLS: config.port == 465,
+		insecure:    config.insecure,
+	}
+}
+
+func (s SMTP) Send(ctx context.Context, email Email) error {
+	if len(email.To) == 0 {
+		return errors.New("no recipient")
+	}
+
+	content, err := s.message(email)
+	if err != nil {
+		return fmt.Errorf("build message: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("dial: %w", err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("client: %w", err)
+	}
+
+	defer func() {
+		_ = client.Close()
+	}()
+
+	if !s.implicitTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+				return fmt.Errorf("starttls: %w", err)
+			}
+		} else if !s.insecure {
+			return ErrNoTLS
+		}
+	}
+
+	if len(s.username) != 0 {
+		if err := client.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+	}
+
+	if err := client.Mail(s.from); err != nil {
+		return fmt.Errorf("mail: %w", err)
+	}
+
+	for _, recipient := range email.To {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("rcpt `%s`: %w", recipient, err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("data: %w", err)
+	}
+
+	if _, err := writer.Write(content); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("close data: %w", err)
+	}
+
+	return client.Quit()
+}
+
+func (s SMTP) dial(ctx context.Context) (net.Conn, error) {
+	if s.implicitTLS {
+		return (&tls.Dialer{Config: &tls.Config{ServerName: s.host}}).DialContext(ctx, "tcp", s.address)
+	}
+
+	return (&net.Dialer{}).DialContext(ctx, "tcp", s.address)
+}
+
+func (s SMTP) message(email Email) ([]byte, error) {
+	boundary, err := newBoundary()
+	if err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+
+	fmt.Fprintf(&buffer, "From: %s\r\n", s.from)
+	fmt.Fprintf(&buffer, "To: %s\r\n", strings.Join(email.To, ", "))
+	fmt.Fprintf(&buffer, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", email.Subject))
+	fmt.Fprintf(&buffer, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buffer.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buffer, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+
+	for _, part := range []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain", email.Text},
+		{"text/html", email.HTML},
+	} {
+		if len(part.content) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&buffer, "--%s\r\n", boundary)
+		fmt.Fprintf(&buffer, "Content-Type: %s; charset=utf-8\r\n", part.contentType)
+		buffer.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+		writer := quotedprintable.NewWriter(&buffer)
+		if _, err := writer.Write([]byte(part.content)); err != nil {
+			return nil, fmt.Errorf("encode: %w", err)
+		}
+
+		if err := writer.Close(); err != nil {
+			return nil, fmt.Errorf("encode: %w", err)
+		}
+
+		buffer.WriteString("\r\n")
+	}
+
+	fmt.Fprintf(&buffer, "--%s--\r\n", boundary)
+
+	return buffer.Bytes(), nil
+}
+
+func newBoundary() (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("boundary: %w", err)
+	}
+
+	return hex.EncodeToString(raw), nil
+}
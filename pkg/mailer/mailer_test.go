@@ -0,0 +1,117 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeServer struct {
+	listener net.Listener
+	messages chan string
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %s", err)
+	}
+
+	server := &fakeServer{listener: listener, messages: make(chan string, 1)}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go server.serve()
+
+	return server
+}
+
+func (fs *fakeServer) serve() {
+	for {
+		conn, err := fs.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go fs.handle(conn)
+	}
+}
+
+func (fs *fakeServer) handle(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	reader := textproto.NewReader(bufio.NewReader(conn))
+	writer := textproto.NewWriter(bufio.NewWriter(conn))
+
+	_ = writer.PrintfLine("220 localhost ESMTP")
+
+	for {
+		line, err := reader.ReadLine()
+		if err != nil {
+			return
+		}
+
+		switch command := strings.ToUpper(strings.Fields(line + " ")[0]); command {
+		case "EHLO", "HELO":
+			_ = writer.PrintfLine("250 localhost")
+		case "MAIL", "RCPT", "RSET", "NOOP":
+			_ = writer.PrintfLine("250 OK")
+		case "DATA":
+			_ = writer.PrintfLine("354 Go ahead")
+
+			content, err := reader.ReadDotBytes()
+			if err != nil {
+				return
+			}
+
+			fs.messages <- string(content)
+			_ = writer.PrintfLine("250 OK")
+		case "QUIT":
+			_ = writer.PrintfLine("221 Bye")
+			return
+		default:
+			_ = writer.PrintfLine("502 Unknown command")
+		}
+	}
+}
+
+func TestSend(t *testing.T) {
+	t.Parallel()
+
+	server := newFakeServer(t)
+
+	host, rawPort, _ := net.SplitHostPort(server.listener.Addr().String())
+	port, _ := strconv.ParseUint(rawPort, 10, 64)
+
+	email := Email{
+		To:      []string{"bob@vibioh.fr"},
+		Subject: "Hello",
+		Text:    "Hello Bob",
+		HTML:    "<p>Hello Bob</p>",
+	}
+
+	if err := New(&Config{host: host, port: uint(port), from: "auth@localhost", timeout: time.Second * 5}).Send(context.Background(), email); !errors.Is(err, ErrNoTLS) {
+		t.Errorf("Send() = `%v`, want `%s`", err, ErrNoTLS)
+	}
+
+	instance := New(&Config{host: host, port: uint(port), from: "auth@localhost", timeout: time.Second * 5, insecure: true})
+
+	if err := instance.Send(context.Background(), email); err != nil {
+		t.Fatalf("Send() = `%s`", err)
+	}
+
+	content := <-server.messages
+
+	for _, want := range []string{"To: bob@vibioh.fr", "Subject: Hello", "Hello Bob", "<p>Hello Bob</p>", "multipart/alternative"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Send() = `%s`, want `%s`", content, want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+type Renderer interface {
+	Render(w io.Writer, name string, content any) error
+}
+
+// Template renders the `<name>-subject`, `<name>-text` and `<name>-html` templates of the renderer.
+// Subject and text parts are unescaped because the renderer escapes for HTML.
+type Template struct {
+	renderer Renderer
+	name     string
+}
+
+func NewTemplate(renderer Renderer, name string) Template {
+	return Template{
+		renderer: renderer,
+		name:     name,
+	}
+}
+
+func (t Template) Email(data any, to ...string) (Email, error) {
+	subject, err := t.render("subject", data)
+	if err != nil {
+		return Email{}, err
+	}
+
+	text, err := t.render("text", data)
+	if err != nil {
+		return Email{}, err
+	}
+
+	content, err := t.render("html", data)
+	if err != nil {
+		return Email{}, err
+	}
+
+	return Email{
+		To:      to,
+		Subject: strings.TrimSpace(html.UnescapeString(subject)),
+		Text:    strings.TrimSpace(html.UnescapeString(text)),
+		HTML:    content,
+	}, nil
+}
+
+func (t Template) render(part string, data any) (string, error) {
+	var output bytes.Buffer
+
+	if err := t.renderer.Render(&output, t.name+"-"+part, data); err != nil {
+		return "", fmt.Errorf("render %s: %w", part, err)
+	}
+
+	return output.String(), nil
+}
@@ -0,0 +1,29 @@
+package mailer_test
+
+import (
+	"testing"
+
+	"github.com/ViBiOh/auth/v3/internal/fake"
+	"github.com/ViBiOh/auth/v3/pkg/mailer"
+)
+
+func TestTemplate(t *testing.T) {
+	t.Parallel()
+
+	email, err := mailer.NewTemplate(fake.NewRenderer(`
+{{ define "welcome-subject" }}Welcome {{ .Name }}{{ end }}
+{{ define "welcome-text" }}Hello {{ .Name }}{{ end }}
+{{ define "welcome-html" }}<p>Hello {{ .Name }}</p>{{ end }}
+`), "welcome").Email(map[string]string{"Name": "<Bob>"}, "bob@vibioh.fr")
+	if err != nil {
+		t.Fatalf("Email() = `%s`", err)
+	}
+
+	if email.Subject != "Welcome <Bob>" || email.Text != "Hello <Bob>" || email.HTML != "<p>Hello &lt;Bob&gt;</p>" {
+		t.Errorf("Email() = %+v", email)
+	}
+
+	if _, err := mailer.NewTemplate(fake.NewRenderer(""), "unknown").Email(nil); err == nil {
+		t.Error("Email() = nil, want an error for an unknown template")
+	}
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"time"
+)
+
+type Invitation struct {
+	Expiration  time.Time `json:"expiration,omitzero"`
+	Creation    time.Time `json:"creation"`
+	UserID      string    `json:"user_id"`
+	Creator     string    `json:"creator,omitempty"`
+	Token       string    `json:"-"`
+	Description string    `json:"description"`
+	Provider    string    `json:"provider,omitempty"`
+	Email       string    `json:"email,omitempty"`
+	Delivery    string    `json:"delivery,omitempty"`
+	Profiles    []string  `json:"profiles,omitempty"`
+	MaxUses     int       `json:"max_uses"`
+	Uses        int       `json:"uses"`
+}
+
+func (i Invitation) User() User {
+	return User{
+		ID:   i.UserID,
+		Name: i.Description,
+		Kind: Invite,
+	}
+}
+
+func (i Invitation) IsMultiUse() bool {
+	return i.MaxUses > 1
+}
+
+func (i Invitation) AllowProvider(name string) bool {
+	return len(i.Provider) == 0 || i.Provider == name
+}
+
+func (i Invitation) IsExpired(now time.Time) bool {
+	return !i.Expiration.IsZero() && !now.Before(i.Expiration)
+}
+
+func (i Invitation) IsExhausted() bool {
+	return i.Uses >= i.MaxUses
+}
+
+func (i Invitation) Status(now time.Time) string {
+	switch {
+	case i.IsExpired(now):
+		return "expired"
+	case i.IsExhausted():
+		return "exhausted"
+	default:
+		return "active"
+	}
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInvitation(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	cases := map[string]struct {
+		instance      Invitation
+		wantExpired   bool
+		wantExhausted bool
+		wantGitHub    bool
+	}{
+		"fresh": {
+			Invitation{MaxUses: 1},
+			false,
+			false,
+			true,
+		},
+		"expired": {
+			Invitation{MaxUses: 1, Expiration: now.Add(-time.Minute)},
+			true,
+			false,
+			true,
+		},
+		"exhausted": {
+			Invitation{MaxUses: 10, Uses: 10, Expiration: now.Add(time.Minute)},
+			false,
+			true,
+			true,
+		},
+		"other provider": {
+			Invitation{MaxUses: 10, Uses: 2, Provider: "google"},
+			false,
+			false,
+			false,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			if got := testCase.instance.IsExpired(now); got != testCase.wantExpired {
+				t.Errorf("IsExpired() = %t, want %t", got, testCase.wantExpired)
+			}
+
+			if got := testCase.instance.IsExhausted(); got != testCase.wantExhausted {
+				t.Errorf("IsExhausted() = %t, want %t", got, testCase.wantExhausted)
+			}
+
+			if got := testCase.instance.AllowProvider("github"); got != testCase.wantGitHub {
+				t.Errorf("AllowProvider() = %t, want %t", got, testCase.wantGitHub)
+			}
+		})
+	}
+}
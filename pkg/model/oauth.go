@@ -5,8 +5,9 @@ import (
 )
 
 type OAuthClaim struct {
-	Token *oauth2.Token `json:"token"`
-	User  User          `json:"user"`
+	Token    *oauth2.Token `json:"token"`
+	Provider string        `json:"provider,omitempty"`
+	User     User          `json:"user"`
 }
 
 func (oac OAuthClaim) GetSubject() string {
@@ -33,11 +34,22 @@ func (gu GitHubUser) GetID() uint64 {
 }
 
 type GoogleUser struct {
-	Sub     string `json:"sub"`
-	Name    string `json:"name"`
-	Picture string `json:"picture"`
+	Sub           string `json:"sub"`
+	Name          string `json:"name"`
+	Picture       string `json:"picture"`
+	Email         string `json:"email"`
+	HostedDomain  string `json:"hd"`
+	EmailVerified bool   `json:"email_verified"`
 }
 
 func (gu GoogleUser) GetID() string {
 	return gu.Sub
 }
+
+func (gu GoogleUser) VerifiedEmail() string {
+	if gu.EmailVerified {
+		return gu.Email
+	}
+
+	return ""
+}
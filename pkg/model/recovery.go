@@ -0,0 +1,11 @@
+package model
+
+import (
+	"time"
+)
+
+type RecoveryCode struct {
+	Used time.Time
+	ID   string
+	Hash string
+}
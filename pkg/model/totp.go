@@ -0,0 +1,7 @@
+package model
+
+type TOTP struct {
+	Secret    []byte
+	LastStep  int64
+	Confirmed bool
+}
@@ -19,9 +19,18 @@ const (
 )
 
 type User struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Image      string     `json:"image"`
+	Email      string     `json:"email,omitempty"`
+	Identities []Identity `json:"identities,omitempty"`
+	Kind       UserKind   `json:"kind"`
+}
+
+type Identity struct {
 	ID    string   `json:"id"`
 	Name  string   `json:"name"`
-	Image string   `json:"image"`
+	Image string   `json:"image,omitempty"`
 	Kind  UserKind `json:"kind"`
 }
 
@@ -59,6 +68,8 @@ const (
 	Discord
 	Basic
 	Google
+	Email
+	Passkey
 )
 
 var ErrUnknownUserKind = errors.New("unknown UserKind")
@@ -13,11 +13,13 @@ func _() {
 	_ = x[Discord-2]
 	_ = x[Basic-3]
 	_ = x[Google-4]
+	_ = x[Email-5]
+	_ = x[Passkey-6]
 }
 
-const _UserKind_name = "InviteGitHubDiscordBasicGoogle"
+const _UserKind_name = "InviteGitHubDiscordBasicGoogleEmailPasskey"
 
-var _UserKind_index = [...]uint8{0, 6, 12, 19, 24, 30}
+var _UserKind_index = [...]uint8{0, 6, 12, 19, 24, 30, 35, 42}
 
 func (i UserKind) String() string {
 	idx := int(i) - 0
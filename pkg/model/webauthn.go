@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+type WebAuthnCredential struct {
+	Creation  time.Time `json:"creation"`
+	LastUsed  time.Time `json:"last_used,omitzero"`
+	UserID    string    `json:"-"`
+	Name      string    `json:"name"`
+	ID        []byte    `json:"id"`
+	PublicKey []byte    `json:"-"`
+	SignCount uint32    `json:"-"`
+}
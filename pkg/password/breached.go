@@ -0,0 +1,118 @@
+package password
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+type BreachChecker interface {
+	Count(ctx context.Context, password string) (int, error)
+}
+
+// BreachedFile looks up passwords in a local copy of the HIBP SHA-1 list: one `HASH:COUNT` line per hash, sorted by hash
+type BreachedFile struct {
+	path string
+}
+
+func NewBreachedFile(path string) (BreachedFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return BreachedFile{}, fmt.Errorf("open: %w", err)
+	}
+
+	return BreachedFile{path: path}, file.Close()
+}
+
+func (bf BreachedFile) Count(_ context.Context, password string) (int, error) {
+	hash := sha1.Sum([]byte(password))
+	target := []byte(hex.EncodeToString(hash[:]))
+
+	file, err := os.Open(bf.path)
+	if err != nil {
+		return 0, fmt.Errorf("open: %w", err)
+	}
+
+	defer func() { _ = file.Close() }()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("stat: %w", err)
+	}
+
+	low, high := int64(0), info.Size()
+
+	for low < high {
+		middle := low + (high-low)/2
+
+		start, line, err := lineFrom(file, middle)
+		if err != nil {
+			return 0, err
+		}
+
+		if start >= high {
+			high = middle
+			continue
+		}
+
+		lineHash, count, _ := bytes.Cut(line, []byte(":"))
+
+		switch comparison := bytes.Compare(bytes.ToLower(lineHash), target); {
+		case comparison == 0:
+			value, err := strconv.Atoi(string(bytes.TrimSpace(count)))
+			if err != nil {
+				return 0, fmt.Errorf("parse count: %w", err)
+			}
+
+			return value, nil
+
+		case comparison < 0:
+			low = start + int64(len(line)) + 1
+
+		default:
+			high = middle
+		}
+	}
+
+	return 0, nil
+}
+
+// lineFrom returns the first line starting at or after offset
+func lineFrom(file io.ReaderAt, offset int64) (int64, []byte, error) {
+	start := offset
+
+	if offset > 0 {
+		var previous [1]byte
+		if _, err := file.ReadAt(previous[:], offset-1); err != nil {
+			return 0, nil, fmt.Errorf("read: %w", err)
+		}
+
+		if previous[0] != '\n' {
+			reader := bufio.NewReader(io.NewSectionReader(file, offset, 1<<62))
+
+			skipped, err := reader.ReadBytes('\n')
+			if err == io.EOF {
+				return offset + int64(len(skipped)), nil, nil
+			} else if err != nil {
+				return 0, nil, fmt.Errorf("read: %w", err)
+			}
+
+			start = offset + int64(len(skipped))
+		}
+	}
+
+	reader := bufio.NewReader(io.NewSectionReader(file, start, 1<<62))
+
+	line, err := reader.ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return 0, nil, fmt.Errorf("read: %w", err)
+	}
+
+	return start, bytes.TrimSuffix(bytes.TrimSuffix(line, []byte("\n")), []byte("\r")), nil
+}
@@ -0,0 +1,284 @@
+package password
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/ViBiOh/auth/v3/internal/secret"
+	"github.com/ViBiOh/auth/v3/pkg/model"
+	"github.com/ViBiOh/flags"
+	"github.com/ViBiOh/httputils/v4/pkg/httperror"
+	"github.com/ViBiOh/httputils/v4/pkg/httpjson"
+)
+
+var (
+	ErrInvalidToken  = errors.New("reset token is invalid or expired")
+	ErrEmptyPassword = errors.New("password is required")
+)
+
+type Storage interface {
+	DoAtomic(ctx context.Context, action func(context.Context) error) error
+
+	GetBasicUser(ctx context.Context, login, password string) (model.User, error)
+	GetBasicLogin(ctx context.Context, user model.User) (string, error)
+	GetBasicUserByLogin(ctx context.Context, login string) (model.User, error)
+	UpdatePassword(ctx context.Context, user model.User, password string) error
+
+	SavePasswordReset(ctx context.Context, user model.User, tokenHash string, expiration time.Time) error
+	ConsumePasswordReset(ctx context.Context, tokenHash string) (model.User, error)
+	RevokeSessions(ctx context.Context, user model.User) error
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, user model.User, token string) error
+}
+
+type NotifierFunc func(ctx context.Context, user model.User, token string) error
+
+func (nf NotifierFunc) Notify(ctx context.Context, user model.User, token string) error {
+	return nf(ctx, user, token)
+}
+
+type Config struct {
+	breachedFile string
+	denylist     []string
+	minLength    int
+	ttl          time.Duration
+}
+
+type Service struct {
+	storage  Storage
+	notifier Notifier
+	policy   Policy
+	ttl      time.Duration
+}
+
+type Option func(Service) Service
+
+func WithPolicy(policy Policy) Option {
+	return func(instance Service) Service {
+		instance.policy = policy
+
+		return instance
+	}
+}
+
+func Flags(fs *flag.FlagSet, prefix string, overrides ...flags.Override) *Config {
+	var config Config
+
+	flags.New("ResetTTL", "Validity of a password reset token").Prefix(prefix).DocPrefix("password").DurationVar(fs, &config.ttl, time.Hour, overrides)
+	flags.New("MinLength", "Minimum password length").Prefix(prefix).DocPrefix("password").IntVar(fs, &config.minLength, 8, overrides)
+	flags.New("Denylist", "Passwords that are refused").Prefix(prefix).DocPrefix("password").StringSliceVar(fs, &config.denylist, nil, overrides)
+	flags.New("BreachedFile", "Path to a HIBP SHA-1 file sorted by hash, for refusing breached passwords").Prefix(prefix).DocPrefix("password").StringVar(fs, &config.breachedFile, "", overrides)
+
+	return &config
+}
+
+func New(config *Config, storage Storage, notifier Notifier, options ...Option) (Service, error) {
+	policy, err := NewPolicy(config)
+	if err != nil {
+		return Service{}, fmt.Errorf("policy: %w", err)
+	}
+
+	service := Service{
+		storage:  storage,
+		notifier: notifier,
+		policy:   policy,
+		ttl:      config.ttl,
+	}
+
+	for _, option := range options {
+		service = option(service)
+	}
+
+	return service, nil
+}
+
+func NewPolicy(config *Config) (Rules, error) {
+	rules := Rules{MinLength(config.minLength), NotSimilarToLogin()}
+
+	if len(config.denylist) != 0 {
+		rules = append(rules, Denylist(config.denylist...))
+	}
+
+	if len(config.breachedFile) != 0 {
+		breachedFile, err := NewBreachedFile(config.breachedFile)
+		if err != nil {
+			return nil, fmt.Errorf("breached file: %w", err)
+		}
+
+		rules = append(rules, Breached(breachedFile))
+	}
+
+	return rules, nil
+}
+
+func (s Service) Change(ctx context.Context, user model.User, current, password string) error {
+	if len(password) == 0 {
+		return ErrEmptyPassword
+	}
+
+	login, err := s.storage.GetBasicLogin(ctx, user)
+	if err != nil {
+		if errors.Is(err, model.ErrUnknownUser) {
+			return model.ErrInvalidCredentials
+		}
+
+		return fmt.Errorf("get login: %w", err)
+	}
+
+	basicUser, err := s.storage.GetBasicUser(ctx, login, current)
+	if err != nil {
+		return err
+	}
+
+	if basicUser.ID != user.ID {
+		return model.ErrInvalidCredentials
+	}
+
+	if err := s.policy.Validate(ctx, login, password); err != nil {
+		return err
+	}
+
+	if err := s.storage.UpdatePassword(ctx, user, password); err != nil {
+		return fmt.Errorf("update password: %w", err)
+	}
+
+	return nil
+}
+
+func (s Service) RequestReset(ctx context.Context, login string) error {
+	user, err := s.storage.GetBasicUserByLogin(ctx, login)
+	if err != nil {
+		if errors.Is(err, model.ErrUnknownUser) {
+			slog.LogAttrs(ctx, slog.LevelWarn, "password reset for unknown login", slog.String("login", login))
+			return nil
+		}
+
+		return fmt.Errorf("get user: %w", err)
+	}
+
+	token, err := secret.New()
+	if err != nil {
+		return err
+	}
+
+	if err := s.storage.SavePasswordReset(ctx, user, secret.Hash(token), time.Now().Add(s.ttl)); err != nil {
+		return fmt.Errorf("save reset: %w", err)
+	}
+
+	if err := s.notifier.Notify(ctx, user, token); err != nil {
+		return fmt.Errorf("notify: %w", err)
+	}
+
+	return nil
+}
+
+func (s Service) Reset(ctx context.Context, token, password string) error {
+	if len(password) == 0 {
+		return ErrEmptyPassword
+	}
+
+	if len(token) == 0 {
+		return ErrInvalidToken
+	}
+
+	return s.storage.DoAtomic(ctx, func(ctx context.Context) error {
+		user, err := s.storage.ConsumePasswordReset(ctx, secret.Hash(token))
+		if err != nil {
+			if errors.Is(err, model.ErrUnknownUser) {
+				return ErrInvalidToken
+			}
+
+			return fmt.Errorf("consume reset: %w", err)
+		}
+
+		login, err := s.storage.GetBasicLogin(ctx, user)
+		if err != nil {
+			return fmt.Errorf("get login: %w", err)
+		}
+
+		// An invalid password rolls back the transaction, the token remains usable
+		if err := s.policy.Validate(ctx, login, password); err != nil {
+			return err
+		}
+
+		if err := s.storage.UpdatePassword(ctx, user, password); err != nil {
+			return fmt.Errorf("update password: %w", err)
+		}
+
+		if err := s.storage.RevokeSessions(ctx, user); err != nil {
+			return fmt.Errorf("revoke sessions: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (s Service) Mux(prefix string, mux *http.ServeMux) {
+	mux.HandleFunc("POST "+prefix+"/change", s.handleChange)
+}
+
+func (s Service) ResetMux(prefix string, mux *http.ServeMux) {
+	mux.HandleFunc("POST "+prefix+"/reset", s.handleRequestReset)
+	mux.HandleFunc("POST "+prefix+"/reset/confirm", s.handleReset)
+}
+
+func (s Service) handleChange(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user := model.ReadUser(ctx)
+	if len(user.ID) == 0 {
+		httperror.Unauthorized(ctx, w, errors.New("login is required"))
+		return
+	}
+
+	if err := s.Change(ctx, user, r.FormValue("current"), r.FormValue("password")); err != nil {
+		handleError(ctx, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s Service) handleRequestReset(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if err := s.RequestReset(ctx, r.FormValue("login")); err != nil {
+		httperror.InternalServerError(ctx, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s Service) handleReset(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if err := s.Reset(ctx, r.FormValue("token"), r.FormValue("password")); err != nil {
+		handleError(ctx, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func handleError(ctx context.Context, w http.ResponseWriter, err error) {
+	var validation ValidationError
+
+	switch {
+	case errors.As(err, &validation):
+		httpjson.Write(ctx, w, http.StatusBadRequest, validation)
+	case errors.Is(err, model.ErrInvalidCredentials):
+		httperror.Unauthorized(ctx, w, err)
+	case errors.Is(err, ErrInvalidToken), errors.Is(err, ErrEmptyPassword):
+		httperror.BadRequest(ctx, w, err)
+	default:
+		httperror.InternalServerError(ctx, w, err)
+	}
+}
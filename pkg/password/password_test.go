@@ -0,0 +1,246 @@
+package password
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/ViBiOh/auth/v3/internal/secret"
+	"github.com/ViBiOh/auth/v3/pkg/model"
+)
+
+type reset struct {
+	expiration time.Time
+	userID     string
+}
+
+type testStorage struct {
+	passwords  map[string]string
+	resets     map[string]reset
+	revocation map[string]time.Time
+}
+
+func newTestStorage() *testStorage {
+	return &testStorage{
+		passwords:  map[string]string{"admin": "secret"},
+		resets:     make(map[string]reset),
+		revocation: make(map[string]time.Time),
+	}
+}
+
+func (ts *testStorage) DoAtomic(ctx context.Context, action func(context.Context) error) error {
+	return action(ctx)
+}
+
+func (ts *testStorage) GetBasicUser(_ context.Context, login, password string) (model.User, error) {
+	if current, ok := ts.passwords[login]; ok && current == password {
+		return model.User{ID: login, Name: login}, nil
+	}
+
+	return model.User{}, model.ErrInvalidCredentials
+}
+
+func (ts *testStorage) GetBasicLogin(_ context.Context, user model.User) (string, error) {
+	if _, ok := ts.passwords[user.ID]; ok {
+		return user.ID, nil
+	}
+
+	return "", model.ErrUnknownUser
+}
+
+func (ts *testStorage) GetBasicUserByLogin(_ context.Context, login string) (model.User, error) {
+	if _, ok := ts.passwords[login]; ok {
+		return model.User{ID: login, Name: login}, nil
+	}
+
+	return model.User{}, model.ErrUnknownUser
+}
+
+func (ts *testStorage) UpdatePassword(_ context.Context, user model.User, password string) error {
+	ts.passwords[user.ID] = password
+
+	return nil
+}
+
+func (ts *testStorage) SavePasswordReset(_ context.Context, user model.User, tokenHash string, expiration time.Time) error {
+	for hash, item := range ts.resets {
+		if item.userID == user.ID {
+			delete(ts.resets, hash)
+		}
+	}
+
+	ts.resets[tokenHash] = reset{userID: user.ID, expiration: expiration}
+
+	return nil
+}
+
+func (ts *testStorage) ConsumePasswordReset(_ context.Context, tokenHash string) (model.User, error) {
+	item, ok := ts.resets[tokenHash]
+	delete(ts.resets, tokenHash)
+
+	if !ok || time.Now().After(item.expiration) {
+		return model.User{}, model.ErrUnknownUser
+	}
+
+	return model.User{ID: item.userID}, nil
+}
+
+func (ts *testStorage) RevokeSessions(_ context.Context, user model.User) error {
+	ts.revocation[user.ID] = time.Now()
+
+	return nil
+}
+
+type testNotifier struct {
+	tokens []string
+}
+
+func (tn *testNotifier) Notify(_ context.Context, _ model.User, token string) error {
+	tn.tokens = append(tn.tokens, token)
+
+	return nil
+}
+
+func newTestService(t *testing.T, storage Storage, notifier Notifier) Service {
+	t.Helper()
+
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	config := Flags(fs, "")
+
+	if err := fs.Parse(nil); err != nil {
+		t.Fatalf("parse flags: %s", err)
+	}
+
+	service, err := New(config, storage, notifier)
+	if err != nil {
+		t.Fatalf("new: %s", err)
+	}
+
+	return service
+}
+
+func TestChange(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		user     model.User
+		current  string
+		password string
+		wantErr  error
+	}{
+		"empty password": {
+			model.User{ID: "admin"},
+			"secret",
+			"",
+			ErrEmptyPassword,
+		},
+		"not a basic user": {
+			model.User{ID: "github"},
+			"secret",
+			"correct horse",
+			model.ErrInvalidCredentials,
+		},
+		"wrong current": {
+			model.User{ID: "admin"},
+			"guess",
+			"correct horse",
+			model.ErrInvalidCredentials,
+		},
+		"policy": {
+			model.User{ID: "admin"},
+			"secret",
+			"admin123",
+			ValidationError{},
+		},
+		"valid": {
+			model.User{ID: "admin"},
+			"secret",
+			"correct horse",
+			nil,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			storage := newTestStorage()
+
+			gotErr := newTestService(t, storage, nil).Change(context.Background(), testCase.user, testCase.current, testCase.password)
+			var validation ValidationError
+			if _, ok := testCase.wantErr.(ValidationError); ok && errors.As(gotErr, &validation) {
+				return
+			}
+
+			if !errors.Is(gotErr, testCase.wantErr) {
+				t.Errorf("Change() = `%v`, want `%v`", gotErr, testCase.wantErr)
+			}
+
+			if testCase.wantErr == nil && storage.passwords["admin"] != testCase.password {
+				t.Errorf("password = `%s`, want `%s`", storage.passwords["admin"], testCase.password)
+			}
+		})
+	}
+}
+
+func TestReset(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	storage := newTestStorage()
+	notifier := &testNotifier{}
+	instance := newTestService(t, storage, notifier)
+
+	if err := instance.RequestReset(ctx, "unknown"); err != nil || len(notifier.tokens) != 0 {
+		t.Errorf("RequestReset() unknown = `%v` with %d notifications, want silent", err, len(notifier.tokens))
+	}
+
+	if err := instance.RequestReset(ctx, "admin"); err != nil {
+		t.Fatalf("RequestReset() = `%s`", err)
+	}
+
+	if err := instance.RequestReset(ctx, "admin"); err != nil {
+		t.Fatalf("RequestReset() = `%s`", err)
+	}
+
+	if len(notifier.tokens) != 2 {
+		t.Fatalf("notifications = %d, want 2", len(notifier.tokens))
+	}
+
+	if _, ok := storage.resets[secret.Hash(notifier.tokens[1])]; !ok {
+		t.Error("reset token is not stored hashed")
+	}
+
+	if err := instance.Reset(ctx, notifier.tokens[0], "correct horse"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Reset() superseded token = `%v`, want `%s`", err, ErrInvalidToken)
+	}
+
+	if err := instance.Reset(ctx, notifier.tokens[1], "correct horse"); err != nil {
+		t.Errorf("Reset() = `%s`", err)
+	}
+
+	if storage.passwords["admin"] != "correct horse" || storage.revocation["admin"].IsZero() {
+		t.Errorf("Reset() didn't update password or revoke sessions")
+	}
+
+	if err := instance.Reset(ctx, notifier.tokens[1], "again"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Reset() replay = `%v`, want `%s`", err, ErrInvalidToken)
+	}
+}
+
+func TestResetExpired(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	storage := newTestStorage()
+
+	if err := storage.SavePasswordReset(ctx, model.User{ID: "admin"}, secret.Hash("expired"), time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("save: %s", err)
+	}
+
+	if err := newTestService(t, storage, nil).Reset(ctx, "expired", "correct horse"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Reset() = `%v`, want `%s`", err, ErrInvalidToken)
+	}
+}
@@ -0,0 +1,125 @@
+package password
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+type ValidationError struct {
+	Violations []Violation `json:"violations"`
+}
+
+func (ve ValidationError) Error() string {
+	messages := make([]string, 0, len(ve.Violations))
+	for _, violation := range ve.Violations {
+		messages = append(messages, violation.Message)
+	}
+
+	return "invalid password: " + strings.Join(messages, ", ")
+}
+
+type Policy interface {
+	Validate(ctx context.Context, login, password string) error
+}
+
+// Rule returns a nil violation when the password complies with it
+type Rule func(ctx context.Context, login, password string) (*Violation, error)
+
+type Rules []Rule
+
+// Validate runs every rule and returns a ValidationError listing all violations
+func (r Rules) Validate(ctx context.Context, login, password string) error {
+	var output ValidationError
+
+	for _, rule := range r {
+		violation, err := rule(ctx, login, password)
+		if err != nil {
+			return err
+		}
+
+		if violation != nil {
+			output.Violations = append(output.Violations, *violation)
+		}
+	}
+
+	if len(output.Violations) != 0 {
+		return output
+	}
+
+	return nil
+}
+
+func MinLength(length int) Rule {
+	return func(_ context.Context, _, password string) (*Violation, error) {
+		if utf8.RuneCountInString(password) >= length {
+			return nil, nil
+		}
+
+		return &Violation{Rule: "min_length", Message: fmt.Sprintf("must be at least %d characters long", length)}, nil
+	}
+}
+
+func Denylist(words ...string) Rule {
+	denied := make(map[string]struct{}, len(words))
+	for _, word := range words {
+		denied[strings.ToLower(word)] = struct{}{}
+	}
+
+	return func(_ context.Context, _, password string) (*Violation, error) {
+		if _, ok := denied[strings.ToLower(password)]; !ok {
+			return nil, nil
+		}
+
+		return &Violation{Rule: "denylist", Message: "is too common"}, nil
+	}
+}
+
+const minSimilarityLength = 4
+
+func NotSimilarToLogin() Rule {
+	return func(_ context.Context, login, password string) (*Violation, error) {
+		login = strings.ToLower(login)
+		password = strings.ToLower(password)
+
+		if len(login) == 0 {
+			return nil, nil
+		}
+
+		if password != login && password != reverse(login) && (len(login) < minSimilarityLength || !strings.Contains(password, login)) {
+			return nil, nil
+		}
+
+		return &Violation{Rule: "login", Message: "must not be similar to the login"}, nil
+	}
+}
+
+func Breached(checker BreachChecker) Rule {
+	return func(ctx context.Context, _, password string) (*Violation, error) {
+		count, err := checker.Count(ctx, password)
+		if err != nil {
+			return nil, fmt.Errorf("breach check: %w", err)
+		}
+
+		if count == 0 {
+			return nil, nil
+		}
+
+		return &Violation{Rule: "breached", Message: "appears in a known data breach"}, nil
+	}
+}
+
+func reverse(value string) string {
+	runes := []rune(value)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+
+	return string(runes)
+}
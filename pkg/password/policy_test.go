@@ -0,0 +1,140 @@
+package password
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	policy := Rules{MinLength(8), NotSimilarToLogin(), Denylist("Password123")}
+
+	cases := map[string]struct {
+		login    string
+		password string
+		want     []string
+	}{
+		"valid": {
+			"admin",
+			"correct horse",
+			nil,
+		},
+		"too short": {
+			"admin",
+			"short",
+			[]string{"min_length"},
+		},
+		"denied": {
+			"admin",
+			"password123",
+			[]string{"denylist"},
+		},
+		"contains login": {
+			"vibioh",
+			"my-vibioh-password",
+			[]string{"login"},
+		},
+		"reversed login": {
+			"administrator",
+			"rotartsinimda",
+			[]string{"login"},
+		},
+		"short login": {
+			"bob",
+			"bobby tables",
+			nil,
+		},
+		"multiple": {
+			"password123",
+			"password123",
+			[]string{"login", "denylist"},
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			err := policy.Validate(context.Background(), testCase.login, testCase.password)
+
+			var got []string
+
+			var validation ValidationError
+			if errors.As(err, &validation) {
+				for _, violation := range validation.Violations {
+					got = append(got, violation.Rule)
+				}
+			} else if err != nil {
+				t.Fatalf("Validate() = `%s`", err)
+			}
+
+			if !reflect.DeepEqual(got, testCase.want) {
+				t.Errorf("Validate() = %v, want %v", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestBreachedFile(t *testing.T) {
+	t.Parallel()
+
+	var lines []string
+	for i, password := range []string{"password", "123456", "qwerty", "letmein", "dragon", "monkey", "sunshine"} {
+		hash := sha1.Sum([]byte(password))
+		lines = append(lines, strings.ToUpper(hex.EncodeToString(hash[:]))+":"+strings.Repeat("9", i+1))
+	}
+
+	slices.Sort(lines)
+
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0o600); err != nil {
+		t.Fatalf("write: %s", err)
+	}
+
+	checker, err := NewBreachedFile(path)
+	if err != nil {
+		t.Fatalf("NewBreachedFile() = `%s`", err)
+	}
+
+	cases := map[string]struct {
+		password string
+		want     int
+	}{
+		"password": {
+			"password",
+			9,
+		},
+		"sunshine": {
+			"sunshine",
+			9999999,
+		},
+		"letmein": {
+			"letmein",
+			9999,
+		},
+		"absent": {
+			"correct horse battery staple",
+			0,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := checker.Count(context.Background(), testCase.password)
+			if err != nil || got != testCase.want {
+				t.Errorf("Count() = (%d, `%v`), want (%d, nil)", got, err, testCase.want)
+			}
+		})
+	}
+}
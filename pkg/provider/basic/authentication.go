@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 
+	"github.com/ViBiOh/auth/v3/pkg/cookie"
 	"github.com/ViBiOh/auth/v3/pkg/model"
 	"github.com/ViBiOh/httputils/v4/pkg/httperror"
 )
@@ -13,7 +15,7 @@ import (
 func (s Service) GetUser(ctx context.Context, w http.ResponseWriter, r *http.Request) (model.User, error) {
 	if s.cookie.IsEnabled() {
 		claim, err := s.cookie.Get(r, cookieName)
-		if err == nil {
+		if err == nil && s.isSessionValid(ctx, claim) {
 			return claim.Content, nil
 		}
 	}
@@ -23,7 +25,7 @@ func (s Service) GetUser(ctx context.Context, w http.ResponseWriter, r *http.Req
 		return model.User{}, model.ErrMalformedContent
 	}
 
-	user, err := s.provider.GetBasicUser(ctx, login, password)
+	user, err := s.authenticate(ctx, login, password, r.Header.Get(otpHeader))
 	if err == nil && s.cookie.IsEnabled() {
 		s.cookie.Set(ctx, w, cookieName, user)
 	}
@@ -31,12 +33,171 @@ func (s Service) GetUser(ctx context.Context, w http.ResponseWriter, r *http.Req
 	return user, err
 }
 
+func (s Service) isSessionValid(ctx context.Context, claim cookie.Claim[model.User]) bool {
+	if s.sessions == nil {
+		return true
+	}
+
+	revocation, err := s.sessions.GetSessionRevocation(ctx, claim.Content)
+	if err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "get session revocation", slog.String("id", claim.Content.ID), slog.Any("error", err))
+		return false
+	}
+
+	return revocation.IsZero() || (claim.IssuedAt != nil && !claim.IssuedAt.Before(revocation))
+}
+
+func (s Service) Login(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := r.Context()
+
+	user, err := s.verify(ctx, r.FormValue("login"), r.FormValue("password"))
+	if err != nil {
+		unauthorized(w, r, err)
+		return
+	}
+
+	required, err := s.isSecondFactorRequired(ctx, user)
+	if err != nil {
+		unauthorized(w, r, err)
+		return
+	}
+
+	if required {
+		if !s.pending.IsEnabled() || s.pending.Set(ctx, w, pendingCookieName, user) {
+			unauthorized(w, r, ErrSecondFactorRequired)
+		}
+
+		return
+	}
+
+	if s.cookie.IsEnabled() && !s.cookie.Set(ctx, w, cookieName, user) {
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s Service) LoginSecondFactor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, err := s.PendingUser(r)
+	if err != nil {
+		unauthorized(w, r, err)
+		return
+	}
+
+	if err := s.secondFactor.Verify(ctx, user, r.FormValue("otp")); err != nil {
+		unauthorized(w, r, fmt.Errorf("%w: %w", model.ErrInvalidCredentials, err))
+		return
+	}
+
+	s.pending.Clear(w, pendingCookieName)
+
+	if s.cookie.Set(ctx, w, cookieName, user) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s Service) PendingUser(r *http.Request) (model.User, error) {
+	if s.secondFactor == nil || !s.pending.IsEnabled() {
+		return model.User{}, model.ErrMalformedContent
+	}
+
+	claim, err := s.pending.Get(r, pendingCookieName)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	if !s.isSessionValid(r.Context(), claim) {
+		return model.User{}, model.ErrInvalidCredentials
+	}
+
+	return claim.Content, nil
+}
+
+func (s Service) authenticate(ctx context.Context, login, password, code string) (model.User, error) {
+	user, err := s.verify(ctx, login, password)
+	if err != nil {
+		return user, err
+	}
+
+	required, err := s.isSecondFactorRequired(ctx, user)
+	if err != nil || !required {
+		return user, err
+	}
+
+	if len(code) == 0 {
+		return model.User{}, ErrSecondFactorRequired
+	}
+
+	if err := s.secondFactor.Verify(ctx, user, code); err != nil {
+		return model.User{}, fmt.Errorf("%w: %w", model.ErrInvalidCredentials, err)
+	}
+
+	return user, nil
+}
+
+func (s Service) isSecondFactorRequired(ctx context.Context, user model.User) (bool, error) {
+	if s.secondFactor == nil {
+		return false, nil
+	}
+
+	required, err := s.secondFactor.Required(ctx, user)
+	if err != nil {
+		return false, fmt.Errorf("second factor: %w", err)
+	}
+
+	return required, nil
+}
+
+func (s Service) verify(ctx context.Context, login, password string) (model.User, error) {
+	if s.verification == nil {
+		return s.provider.GetBasicUser(ctx, login, password)
+	}
+
+	if user, ok := s.verification.Get(login, password); ok {
+		return user, nil
+	}
+
+	generation := s.verification.Generation()
+
+	user, err := s.provider.GetBasicUser(ctx, login, password)
+	if err == nil {
+		s.verification.Set(generation, login, password, user)
+	}
+
+	return user, err
+}
+
 func (s Service) OnUnauthorized(w http.ResponseWriter, r *http.Request, err error) {
 	if errors.Is(err, model.ErrMalformedContent) {
 		err = nil // We don't want to log it
 	}
 
-	w.Header().Add("WWW-Authenticate", fmt.Sprintf("Basic %scharset=\"UTF-8\"", s.realm))
+	if !errors.Is(err, model.ErrUnavailableService) {
+		w.Header().Add("WWW-Authenticate", fmt.Sprintf("Basic %scharset=\"UTF-8\"", s.realm))
+	}
+
+	unauthorized(w, r, err)
+}
+
+// unauthorized doesn't challenge for Basic auth, browsers would pop their dialog over the login form
+func unauthorized(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, model.ErrUnavailableService) {
+		w.Header().Set("Retry-After", "5")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	if errors.Is(err, ErrSecondFactorRequired) {
+		w.Header().Set(otpHeader, "required")
+	}
+
 	httperror.Unauthorized(r.Context(), w, err)
 }
 
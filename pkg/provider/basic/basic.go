@@ -2,14 +2,23 @@ package basic
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/ViBiOh/auth/v3/pkg/cookie"
 	"github.com/ViBiOh/auth/v3/pkg/model"
 )
 
-const cookieName = "_basic_auth"
+const (
+	cookieName        = "_basic_auth"
+	pendingCookieName = "_basic_pending"
+	pendingTTL        = time.Minute * 5
+	otpHeader         = "X-OTP"
+)
+
+var ErrSecondFactorRequired = errors.New("second factor required")
 
 var _ model.Authentication = Service{}
 
@@ -17,13 +26,26 @@ type Provider interface {
 	GetBasicUser(ctx context.Context, login, password string) (model.User, error)
 }
 
+type SecondFactor interface {
+	Required(ctx context.Context, user model.User) (bool, error)
+	Verify(ctx context.Context, user model.User, code string) error
+}
+
+type SessionStorage interface {
+	GetSessionRevocation(ctx context.Context, user model.User) (time.Time, error)
+}
+
 type ForbiddenHandler func(http.ResponseWriter, *http.Request, model.User, string)
 
 type Service struct {
-	provider    Provider
-	onForbidden ForbiddenHandler
-	realm       string
-	cookie      cookie.Service[model.User]
+	provider     Provider
+	secondFactor SecondFactor
+	sessions     SessionStorage
+	verification *VerificationCache
+	onForbidden  ForbiddenHandler
+	realm        string
+	cookie       cookie.Service[model.User]
+	pending      cookie.Service[model.User]
 }
 
 func New(provider Provider, options ...Option) Service {
@@ -50,9 +72,34 @@ func WithRealm(realm string) Option {
 	}
 }
 
-func WithCookie(cookie cookie.Service[model.User]) Option {
+func WithCookie(cookieService cookie.Service[model.User]) Option {
+	return func(instance Service) Service {
+		instance.cookie = cookieService
+		instance.pending = cookie.Derive[model.User](cookieService, "pending", pendingTTL, http.SameSiteStrictMode)
+
+		return instance
+	}
+}
+
+func WithSecondFactor(secondFactor SecondFactor) Option {
+	return func(instance Service) Service {
+		instance.secondFactor = secondFactor
+
+		return instance
+	}
+}
+
+func WithSessionRevocation(sessions SessionStorage) Option {
+	return func(instance Service) Service {
+		instance.sessions = sessions
+
+		return instance
+	}
+}
+
+func WithVerificationCache(cache *VerificationCache) Option {
 	return func(instance Service) Service {
-		instance.cookie = cookie
+		instance.verification = cache
 
 		return instance
 	}
@@ -3,11 +3,16 @@ package basic
 import (
 	"context"
 	"errors"
+	"flag"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/ViBiOh/auth/v3/pkg/cookie"
 	"github.com/ViBiOh/auth/v3/pkg/model"
 	"github.com/ViBiOh/httputils/v4/pkg/request"
 )
@@ -74,6 +79,213 @@ func TestGetUser(t *testing.T) {
 	}
 }
 
+type testSecondFactor struct{}
+
+func (tsf testSecondFactor) Required(_ context.Context, user model.User) (bool, error) {
+	return user.ID == adminUser.ID, nil
+}
+
+func (tsf testSecondFactor) Verify(_ context.Context, _ model.User, code string) error {
+	if code == "123456" {
+		return nil
+	}
+
+	return errors.New("invalid code")
+}
+
+func TestGetUserSecondFactor(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		code    string
+		want    model.User
+		wantErr error
+	}{
+		"missing code": {
+			"",
+			model.User{},
+			ErrSecondFactorRequired,
+		},
+		"invalid code": {
+			"000000",
+			model.User{},
+			model.ErrInvalidCredentials,
+		},
+		"valid code": {
+			"123456",
+			adminUser,
+			nil,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			req := getRequestWithAuthorization("admin", "secret")
+			if len(testCase.code) != 0 {
+				req.Header.Set(otpHeader, testCase.code)
+			}
+
+			got, gotErr := New(testProvider{}, WithSecondFactor(testSecondFactor{})).GetUser(context.Background(), httptest.NewRecorder(), req)
+
+			if !errors.Is(gotErr, testCase.wantErr) || !reflect.DeepEqual(got, testCase.want) {
+				t.Errorf("GetUser() = (%+v, `%s`), want (%+v, `%s`)", got, gotErr, testCase.want, testCase.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoginSecondFactor(t *testing.T) {
+	t.Parallel()
+
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	config := cookie.Flags(fs, "")
+
+	if err := fs.Parse([]string{"-hmacSecret", "secret"}); err != nil {
+		t.Fatalf("parse cookie flags: %s", err)
+	}
+
+	instance := New(testProvider{}, WithCookie(cookie.New[model.User](config)), WithSecondFactor(testSecondFactor{}))
+
+	cases := map[string]struct {
+		password       string
+		code           string
+		wantLogin      int
+		wantSecondStep int
+	}{
+		"invalid password": {
+			"guest",
+			"123456",
+			http.StatusUnauthorized,
+			http.StatusUnauthorized,
+		},
+		"invalid code": {
+			"secret",
+			"000000",
+			http.StatusUnauthorized,
+			http.StatusUnauthorized,
+		},
+		"valid code": {
+			"secret",
+			"123456",
+			http.StatusUnauthorized,
+			http.StatusNoContent,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(url.Values{"login": {"admin"}, "password": {testCase.password}}.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			writer := httptest.NewRecorder()
+			instance.Login(writer, req)
+
+			if writer.Code != testCase.wantLogin {
+				t.Errorf("Login() = %d, want %d", writer.Code, testCase.wantLogin)
+			}
+
+			if got := writer.Header().Get("WWW-Authenticate"); len(got) != 0 {
+				t.Errorf("Login() WWW-Authenticate = `%s`, want none", got)
+			}
+
+			req = httptest.NewRequest(http.MethodPost, "/login/otp", strings.NewReader(url.Values{"otp": {testCase.code}}.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			for _, item := range writer.Result().Cookies() {
+				req.AddCookie(item)
+			}
+
+			writer = httptest.NewRecorder()
+			instance.LoginSecondFactor(writer, req)
+
+			if writer.Code != testCase.wantSecondStep {
+				t.Errorf("LoginSecondFactor() = %d, want %d", writer.Code, testCase.wantSecondStep)
+			}
+
+			var gotSession bool
+			for _, item := range writer.Result().Cookies() {
+				gotSession = gotSession || item.Name == cookieName
+			}
+
+			if wantSession := testCase.wantSecondStep == http.StatusNoContent; gotSession != wantSession {
+				t.Errorf("LoginSecondFactor() session = %t, want %t", gotSession, wantSession)
+			}
+		})
+	}
+}
+
+type testSessions struct {
+	revocation time.Time
+}
+
+func (ts testSessions) GetSessionRevocation(context.Context, model.User) (time.Time, error) {
+	return ts.revocation, nil
+}
+
+func TestGetUserSessionRevocation(t *testing.T) {
+	t.Parallel()
+
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	config := cookie.Flags(fs, "")
+
+	if err := fs.Parse([]string{"-hmacSecret", "secret"}); err != nil {
+		t.Fatalf("parse cookie flags: %s", err)
+	}
+
+	cookieService := cookie.New[model.User](config)
+
+	cases := map[string]struct {
+		revocation time.Time
+		want       model.User
+		wantErr    error
+	}{
+		"never revoked": {
+			time.Time{},
+			adminUser,
+			nil,
+		},
+		"revoked before login": {
+			time.Now().Add(-time.Hour),
+			adminUser,
+			nil,
+		},
+		"revoked up to login second": {
+			time.Now().Truncate(time.Second),
+			adminUser,
+			nil,
+		},
+		"revoked after login": {
+			time.Now().Add(time.Minute),
+			model.User{},
+			model.ErrMalformedContent,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			writer := httptest.NewRecorder()
+			cookieService.Set(context.Background(), writer, cookieName, adminUser)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for _, item := range writer.Result().Cookies() {
+				req.AddCookie(item)
+			}
+
+			got, gotErr := New(testProvider{}, WithCookie(cookieService), WithSessionRevocation(testSessions{testCase.revocation})).GetUser(context.Background(), httptest.NewRecorder(), req)
+
+			if !errors.Is(gotErr, testCase.wantErr) || !reflect.DeepEqual(got, testCase.want) {
+				t.Errorf("GetUser() = (%+v, `%s`), want (%+v, `%s`)", got, gotErr, testCase.want, testCase.wantErr)
+			}
+		})
+	}
+}
+
 func TestOnUnauthorized(t *testing.T) {
 	t.Parallel()
 
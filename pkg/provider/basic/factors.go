@@ -0,0 +1,55 @@
+package basic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ViBiOh/auth/v3/pkg/model"
+)
+
+type anyOf []SecondFactor
+
+// AnyOf accepts a code from any of the factors the user is enrolled in
+func AnyOf(factors ...SecondFactor) SecondFactor {
+	return anyOf(factors)
+}
+
+func (a anyOf) Required(ctx context.Context, user model.User) (bool, error) {
+	for _, factor := range a {
+		required, err := factor.Required(ctx, user)
+		if err != nil || required {
+			return required, err
+		}
+	}
+
+	return false, nil
+}
+
+func (a anyOf) Verify(ctx context.Context, user model.User, code string) error {
+	var errs []error
+
+	for _, factor := range a {
+		required, err := factor.Required(ctx, user)
+		if err != nil {
+			return fmt.Errorf("second factor: %w", err)
+		}
+
+		if !required {
+			continue
+		}
+
+		err = factor.Verify(ctx, user, code)
+		if err == nil {
+			return nil
+		}
+
+		errs = append(errs, err)
+	}
+
+	if len(errs) == 0 {
+		return errors.New("no second factor enrolled")
+	}
+
+	return errors.Join(errs...)
+}
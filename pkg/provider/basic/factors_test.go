@@ -0,0 +1,72 @@
+package basic
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ViBiOh/auth/v3/pkg/model"
+)
+
+type testFactor struct {
+	enrolled bool
+	code     string
+}
+
+func (tf testFactor) Required(context.Context, model.User) (bool, error) {
+	return tf.enrolled, nil
+}
+
+func (tf testFactor) Verify(_ context.Context, _ model.User, code string) error {
+	if code != tf.code {
+		return errors.New("invalid code")
+	}
+
+	return nil
+}
+
+func TestAnyOf(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		factors      []SecondFactor
+		code         string
+		wantRequired bool
+		wantErr      bool
+	}{
+		"none enrolled": {
+			[]SecondFactor{testFactor{code: "123456"}},
+			"123456",
+			false,
+			true,
+		},
+		"second factor": {
+			[]SecondFactor{testFactor{enrolled: true, code: "passkey"}, testFactor{enrolled: true, code: "123456"}},
+			"123456",
+			true,
+			false,
+		},
+		"not enrolled factor": {
+			[]SecondFactor{testFactor{enrolled: true, code: "passkey"}, testFactor{code: "123456"}},
+			"123456",
+			true,
+			true,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			instance := AnyOf(testCase.factors...)
+
+			if got, _ := instance.Required(context.Background(), adminUser); got != testCase.wantRequired {
+				t.Errorf("Required() = %t, want %t", got, testCase.wantRequired)
+			}
+
+			if gotErr := instance.Verify(context.Background(), adminUser, testCase.code); (gotErr != nil) != testCase.wantErr {
+				t.Errorf("Verify() = `%v`, want error %t", gotErr, testCase.wantErr)
+			}
+		})
+	}
+}
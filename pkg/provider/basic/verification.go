@@ -0,0 +1,118 @@
+package basic
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/ViBiOh/auth/v3/pkg/model"
+)
+
+const maxVerifications = 1024
+
+type verification struct {
+	expiration time.Time
+	user       model.User
+}
+
+type VerificationCache struct {
+	entries    map[string]verification
+	key        []byte
+	ttl        time.Duration
+	generation uint64
+	mutex      sync.Mutex
+}
+
+func NewVerificationCache(ttl time.Duration) *VerificationCache {
+	key := make([]byte, sha256.Size)
+	_, _ = rand.Read(key)
+
+	return &VerificationCache{
+		entries: make(map[string]verification),
+		key:     key,
+		ttl:     ttl,
+	}
+}
+
+func (vc *VerificationCache) Get(login, password string) (model.User, bool) {
+	id := vc.id(login, password)
+
+	vc.mutex.Lock()
+	defer vc.mutex.Unlock()
+
+	entry, ok := vc.entries[id]
+	if !ok {
+		return model.User{}, false
+	}
+
+	if time.Now().After(entry.expiration) {
+		delete(vc.entries, id)
+		return model.User{}, false
+	}
+
+	return entry.user, true
+}
+
+// Generation has to be read before verifying the credentials, a verification started before an invalidation isn't cached
+func (vc *VerificationCache) Generation() uint64 {
+	vc.mutex.Lock()
+	defer vc.mutex.Unlock()
+
+	return vc.generation
+}
+
+func (vc *VerificationCache) Set(generation uint64, login, password string, user model.User) {
+	id := vc.id(login, password)
+	now := time.Now()
+
+	vc.mutex.Lock()
+	defer vc.mutex.Unlock()
+
+	if generation != vc.generation {
+		return
+	}
+
+	if len(vc.entries) >= maxVerifications {
+		for key, entry := range vc.entries {
+			if now.After(entry.expiration) {
+				delete(vc.entries, key)
+			}
+		}
+
+		for key := range vc.entries {
+			if len(vc.entries) < maxVerifications {
+				break
+			}
+
+			delete(vc.entries, key)
+		}
+	}
+
+	vc.entries[id] = verification{user: user, expiration: now.Add(vc.ttl)}
+}
+
+func (vc *VerificationCache) Invalidate(_ context.Context, user model.User) {
+	vc.mutex.Lock()
+	defer vc.mutex.Unlock()
+
+	vc.generation++
+
+	for key, entry := range vc.entries {
+		if entry.user.ID == user.ID {
+			delete(vc.entries, key)
+		}
+	}
+}
+
+func (vc *VerificationCache) id(login, password string) string {
+	mac := hmac.New(sha256.New, vc.key)
+	_, _ = mac.Write(binary.BigEndian.AppendUint64(nil, uint64(len(login))))
+	_, _ = mac.Write([]byte(login))
+	_, _ = mac.Write([]byte(password))
+
+	return string(mac.Sum(nil))
+}
@@ -0,0 +1,125 @@
+package basic
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ViBiOh/auth/v3/pkg/model"
+)
+
+type countingProvider struct {
+	calls atomic.Int64
+}
+
+func (cp *countingProvider) GetBasicUser(ctx context.Context, login, password string) (model.User, error) {
+	cp.calls.Add(1)
+
+	return testProvider{}.GetBasicUser(ctx, login, password)
+}
+
+func TestVerificationCache(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		act       func(*VerificationCache)
+		login     string
+		password  string
+		wantFound bool
+	}{
+		"hit": {
+			func(*VerificationCache) {},
+			"admin",
+			"secret",
+			true,
+		},
+		"other password": {
+			func(*VerificationCache) {},
+			"admin",
+			"guess",
+			false,
+		},
+		"no login and password ambiguity": {
+			func(*VerificationCache) {},
+			"admins",
+			"ecret",
+			false,
+		},
+		"no ambiguity with a NUL in login": {
+			func(cache *VerificationCache) {
+				cache.Set(cache.Generation(), "guest\x00secret", "pass", model.User{ID: "guest"})
+			},
+			"guest",
+			"secret\x00pass",
+			false,
+		},
+		"invalidated": {
+			func(cache *VerificationCache) {
+				cache.Invalidate(context.Background(), adminUser)
+			},
+			"admin",
+			"secret",
+			false,
+		},
+		"verified before invalidation": {
+			func(cache *VerificationCache) {
+				generation := cache.Generation()
+				cache.Invalidate(context.Background(), adminUser)
+				cache.Set(generation, "admin", "secret", adminUser)
+			},
+			"admin",
+			"secret",
+			false,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			cache := NewVerificationCache(time.Minute)
+			cache.Set(cache.Generation(), "admin", "secret", adminUser)
+
+			testCase.act(cache)
+
+			if _, found := cache.Get(testCase.login, testCase.password); found != testCase.wantFound {
+				t.Errorf("Get() = %t, want %t", found, testCase.wantFound)
+			}
+		})
+	}
+}
+
+func TestVerificationCacheExpiration(t *testing.T) {
+	t.Parallel()
+
+	cache := NewVerificationCache(time.Millisecond * 10)
+	cache.Set(cache.Generation(), "admin", "secret", adminUser)
+
+	time.Sleep(time.Millisecond * 20)
+
+	if _, found := cache.Get("admin", "secret"); found {
+		t.Error("Get() found an expired verification")
+	}
+}
+
+func TestGetUserVerificationCache(t *testing.T) {
+	t.Parallel()
+
+	provider := &countingProvider{}
+	instance := New(provider, WithVerificationCache(NewVerificationCache(time.Minute)))
+
+	for range 3 {
+		if _, err := instance.GetUser(context.Background(), nil, getRequestWithAuthorization("admin", "secret")); err != nil {
+			t.Fatalf("GetUser() = `%s`", err)
+		}
+	}
+
+	if _, err := instance.GetUser(context.Background(), nil, getRequestWithAuthorization("admin", "guess")); err == nil {
+		t.Error("GetUser() succeeded with invalid password")
+	}
+
+	if got := provider.calls.Load(); got != 2 {
+		t.Errorf("provider calls = %d, want 2", got)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/url"
 
 	"github.com/ViBiOh/auth/v3/pkg/model"
+	"github.com/ViBiOh/auth/v3/pkg/redirect"
 	"github.com/ViBiOh/httputils/v4/pkg/renderer"
 )
 
@@ -16,18 +17,22 @@ type Logout interface {
 type Provider struct {
 	Auth         model.Authentication
 	RegisterPath string
+	LinkPath     string
+	UnlinkPath   string
 	Kind         model.UserKind
 }
 
 type Service struct {
-	renderer  *renderer.Service
-	providers []Provider
+	renderer    *renderer.Service
+	providers   []Provider
+	redirection redirect.Service
 }
 
-func New(renderer *renderer.Service, providers ...Provider) Service {
+func New(renderer *renderer.Service, redirection redirect.Service, providers ...Provider) Service {
 	return Service{
-		providers: providers,
-		renderer:  renderer,
+		providers:   providers,
+		renderer:    renderer,
+		redirection: redirection,
 	}
 }
 
@@ -46,14 +51,15 @@ func (s Service) GetUser(ctx context.Context, w http.ResponseWriter, r *http.Req
 }
 
 func (s Service) OnUnauthorized(w http.ResponseWriter, r *http.Request, err error) {
-	redirect := r.URL.String()
-
 	type providerLink struct {
 		Name string
 		URL  string
 	}
 
-	redirection := "?redirect=" + url.QueryEscape(redirect)
+	var redirection string
+	if redirect := r.URL.String(); s.redirection.IsAllowed(redirect) {
+		redirection = "?redirect=" + url.QueryEscape(redirect)
+	}
 
 	links := make([]providerLink, 0, len(s.providers))
 	for _, provider := range s.providers {
@@ -68,6 +74,54 @@ func (s Service) OnUnauthorized(w http.ResponseWriter, r *http.Request, err erro
 	}))
 }
 
+func (s Service) Account(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, err := s.GetUser(ctx, w, r)
+	if err != nil {
+		s.OnUnauthorized(w, r, err)
+		return
+	}
+
+	type providerAccount struct {
+		Name       string
+		LinkURL    string
+		UnlinkURL  string
+		Identities []model.Identity
+	}
+
+	redirection := "?redirect=" + url.QueryEscape(r.URL.Path)
+
+	accounts := make([]providerAccount, 0, len(s.providers))
+	for _, provider := range s.providers {
+		account := providerAccount{
+			Name: provider.Kind.String(),
+		}
+
+		for _, identity := range user.Identities {
+			if identity.Kind == provider.Kind {
+				account.Identities = append(account.Identities, identity)
+			}
+		}
+
+		if len(account.Identities) == 0 && len(provider.LinkPath) != 0 {
+			account.LinkURL = provider.LinkPath + redirection
+		}
+
+		if len(account.Identities) != 0 && len(user.Identities) > 1 {
+			account.UnlinkURL = provider.UnlinkPath
+		}
+
+		accounts = append(accounts, account)
+	}
+
+	s.renderer.Serve(w, r, renderer.NewPage("account", http.StatusOK, map[string]any{
+		"User":     user,
+		"Accounts": accounts,
+		"Redirect": r.URL.Path,
+	}))
+}
+
 func (s Service) Logout(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
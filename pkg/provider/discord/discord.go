@@ -3,21 +3,28 @@ package discord
 import (
 	"context"
 	"flag"
+	"strings"
 
 	"github.com/ViBiOh/auth/v3/pkg/cookie"
 	"github.com/ViBiOh/auth/v3/pkg/model"
 	"github.com/ViBiOh/auth/v3/pkg/provider/oauth"
+	"github.com/ViBiOh/auth/v3/pkg/redirect"
 	"github.com/ViBiOh/flags"
 	"github.com/ViBiOh/httputils/v4/pkg/renderer"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/endpoints"
 )
 
+const revokeURL = "https://discord.com/api/oauth2/token/revoke"
+
 type Config struct {
 	clientID      string
 	clientSecret  string
 	redirectURL   string
 	onSuccessPath string
+	guildID       string
+	roles         []string
+	roleProfiles  []string
 }
 
 type Storage interface {
@@ -25,6 +32,9 @@ type Storage interface {
 
 	CreateDiscord(context.Context, model.User, model.DiscordUser) (model.User, error)
 	GetDiscordUser(context.Context, string) (model.User, error)
+	UpdateDiscordUser(context.Context, model.User, model.DiscordUser) (model.User, error)
+	DeleteDiscordUser(context.Context, model.User) error
+	SyncProfiles(context.Context, model.User, []string, []string) error
 }
 
 func Flags(fs *flag.FlagSet, prefix string, overrides ...flags.Override) *Config {
@@ -34,16 +44,43 @@ func Flags(fs *flag.FlagSet, prefix string, overrides ...flags.Override) *Config
 	flags.New("ClientSecret", "Client Secret").Prefix(prefix).DocPrefix("discord").StringVar(fs, &config.clientSecret, "", overrides)
 	flags.New("RedirectURL", "URL used for redirection").Prefix(prefix).DocPrefix("discord").StringVar(fs, &config.redirectURL, "http://127.0.0.1:1080/oauth/discord/callback", overrides)
 	flags.New("OnSuccessPath", "Path for redirecting on success").Prefix(prefix).DocPrefix("discord").StringVar(fs, &config.onSuccessPath, "/", overrides)
+	flags.New("GuildID", "Guild ID required to login").Prefix(prefix).DocPrefix("discord").StringVar(fs, &config.guildID, "", overrides)
+	flags.New("Roles", "Guild role IDs allowed to login").Prefix(prefix).DocPrefix("discord").StringSliceVar(fs, &config.roles, nil, overrides)
+	flags.New("RoleProfiles", "Guild roles mapped to profiles, in the form 'roleID:profile'").Prefix(prefix).DocPrefix("discord").StringSliceVar(fs, &config.roleProfiles, nil, overrides)
 
 	return &config
 }
 
-func New(config *Config, cache oauth.Cache, storage Storage, linkHandler oauth.LinkHandler, renderer *renderer.Service, cookie cookie.Service[model.OAuthClaim]) oauth.Service[model.DiscordUser, string] {
+func New(config *Config, cache oauth.Cache, storage Storage, linkHandler oauth.LinkHandler, renderer *renderer.Service, cookie cookie.Service[model.OAuthClaim], redirection redirect.Service) oauth.Service[model.DiscordUser, string] {
+	scopes := []string{"identify"}
+	var options []oauth.Option[model.DiscordUser, string]
+
+	if len(config.guildID) != 0 {
+		scopes = append(scopes, "guilds.members.read")
+		options = append(options, oauth.WithAccessHandler[model.DiscordUser](access(apiURL, config.guildID, config.roles)))
+
+		if roleProfiles := parseRoleProfiles(config.roleProfiles); len(roleProfiles) != 0 {
+			options = append(options, oauth.WithSyncHandler[model.DiscordUser](syncProfiles(apiURL, config.guildID, roleProfiles, storage)))
+		}
+	}
+
 	return oauth.New("discord", "https://discord.com/api/users/@me", config.onSuccessPath, oauth2.Config{
 		ClientID:     config.clientID,
 		ClientSecret: config.clientSecret,
 		Endpoint:     endpoints.Discord,
 		RedirectURL:  config.redirectURL,
-		Scopes:       []string{"identify"},
-	}, cache, storage, linkHandler, storage.CreateDiscord, storage.GetDiscordUser, renderer, cookie)
+		Scopes:       scopes,
+	}, cache, storage, linkHandler, storage.CreateDiscord, storage.GetDiscordUser, storage.UpdateDiscordUser, storage.DeleteDiscordUser, oauth.TokenRevoker(revokeURL, config.clientID, config.clientSecret), renderer, cookie, redirection, options...)
+}
+
+func parseRoleProfiles(values []string) map[string]string {
+	output := make(map[string]string, len(values))
+
+	for _, value := range values {
+		if role, profile, ok := strings.Cut(value, ":"); ok && len(role) != 0 && len(profile) != 0 {
+			output[role] = profile
+		}
+	}
+
+	return output
 }
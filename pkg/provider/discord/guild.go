@@ -0,0 +1,95 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/ViBiOh/auth/v3/pkg/model"
+	"github.com/ViBiOh/auth/v3/pkg/provider/oauth"
+	"github.com/ViBiOh/httputils/v4/pkg/httpjson"
+)
+
+const apiURL = "https://discord.com/api"
+
+type guildMember struct {
+	Roles []string `json:"roles"`
+}
+
+type profileSyncer interface {
+	SyncProfiles(ctx context.Context, user model.User, managed, granted []string) error
+}
+
+func getMember(ctx context.Context, client *http.Client, apiURL, guildID string) (guildMember, error) {
+	return oauth.Shared(ctx, "discord:member:"+guildID, func() (guildMember, error) {
+		return fetchMember(ctx, client, apiURL, guildID)
+	})
+}
+
+func fetchMember(ctx context.Context, client *http.Client, apiURL, guildID string) (guildMember, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL+"/users/@me/guilds/"+guildID+"/member", nil)
+	if err != nil {
+		return guildMember{}, fmt.Errorf("create request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return guildMember{}, fmt.Errorf("get member: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return httpjson.Read[guildMember](resp)
+
+	case http.StatusNotFound, http.StatusForbidden:
+		_ = resp.Body.Close()
+		return guildMember{}, fmt.Errorf("not a member of Discord guild `%s`: %w", guildID, model.ErrForbidden)
+
+	default:
+		_ = resp.Body.Close()
+		return guildMember{}, fmt.Errorf("get member: unexpected status %d", resp.StatusCode)
+	}
+}
+
+func access(apiURL, guildID string, roles []string) oauth.AccessHandler {
+	return func(ctx context.Context, client *http.Client) error {
+		member, err := getMember(ctx, client, apiURL, guildID)
+		if err != nil {
+			return err
+		}
+
+		if len(roles) == 0 || slices.ContainsFunc(member.Roles, func(role string) bool {
+			return slices.Contains(roles, role)
+		}) {
+			return nil
+		}
+
+		return fmt.Errorf("missing required role in Discord guild `%s`: %w", guildID, model.ErrForbidden)
+	}
+}
+
+func syncProfiles(apiURL, guildID string, roleProfiles map[string]string, syncer profileSyncer) oauth.SyncHandler {
+	managed := make([]string, 0, len(roleProfiles))
+	for _, profile := range roleProfiles {
+		if !slices.Contains(managed, profile) {
+			managed = append(managed, profile)
+		}
+	}
+
+	return func(ctx context.Context, client *http.Client, user model.User) error {
+		member, err := getMember(ctx, client, apiURL, guildID)
+		if err != nil {
+			return err
+		}
+
+		granted := make([]string, 0, len(member.Roles))
+		for _, role := range member.Roles {
+			if profile, ok := roleProfiles[role]; ok && !slices.Contains(granted, profile) {
+				granted = append(granted, profile)
+			}
+		}
+
+		return syncer.SyncProfiles(ctx, user, managed, granted)
+	}
+}
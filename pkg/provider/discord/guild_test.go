@@ -0,0 +1,109 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/ViBiOh/auth/v3/pkg/model"
+)
+
+type testSyncer struct {
+	managed []string
+	granted []string
+}
+
+func (ts *testSyncer) SyncProfiles(_ context.Context, _ model.User, managed, granted []string) error {
+	ts.managed = managed
+	ts.granted = granted
+
+	return nil
+}
+
+func newGuildServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/@me/guilds/vibioh/member":
+			_, _ = w.Write([]byte(`{"roles":["1","2"]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestAccess(t *testing.T) {
+	t.Parallel()
+
+	server := newGuildServer(t)
+
+	cases := map[string]struct {
+		guildID string
+		roles   []string
+		wantErr error
+	}{
+		"member": {
+			"vibioh",
+			nil,
+			nil,
+		},
+		"not member": {
+			"unknown",
+			nil,
+			model.ErrForbidden,
+		},
+		"allowed role": {
+			"vibioh",
+			[]string{"3", "2"},
+			nil,
+		},
+		"missing role": {
+			"vibioh",
+			[]string{"3"},
+			model.ErrForbidden,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			gotErr := access(server.URL, testCase.guildID, testCase.roles)(context.Background(), server.Client())
+
+			if !errors.Is(gotErr, testCase.wantErr) {
+				t.Errorf("access() = `%s`, want `%s`", gotErr, testCase.wantErr)
+			}
+		})
+	}
+}
+
+func TestSyncProfiles(t *testing.T) {
+	t.Parallel()
+
+	server := newGuildServer(t)
+
+	syncer := &testSyncer{}
+
+	err := syncProfiles(server.URL, "vibioh", map[string]string{"1": "admin", "2": "admin", "3": "editor"}, syncer)(context.Background(), server.Client(), model.User{ID: "1"})
+	if err != nil {
+		t.Fatalf("syncProfiles() = `%s`", err)
+	}
+
+	slices.Sort(syncer.managed)
+
+	if want := []string{"admin", "editor"}; !reflect.DeepEqual(syncer.managed, want) {
+		t.Errorf("syncProfiles() managed = %v, want %v", syncer.managed, want)
+	}
+
+	if want := []string{"admin"}; !reflect.DeepEqual(syncer.granted, want) {
+		t.Errorf("syncProfiles() granted = %v, want %v", syncer.granted, want)
+	}
+}
@@ -3,10 +3,12 @@ package github
 import (
 	"context"
 	"flag"
+	"fmt"
 
 	"github.com/ViBiOh/auth/v3/pkg/cookie"
 	"github.com/ViBiOh/auth/v3/pkg/model"
 	"github.com/ViBiOh/auth/v3/pkg/provider/oauth"
+	"github.com/ViBiOh/auth/v3/pkg/redirect"
 	"github.com/ViBiOh/flags"
 	"github.com/ViBiOh/httputils/v4/pkg/renderer"
 	"golang.org/x/oauth2"
@@ -18,6 +20,8 @@ type Config struct {
 	clientSecret  string
 	redirectURL   string
 	onSuccessPath string
+	orgs          []string
+	teams         []string
 }
 
 type Storage interface {
@@ -25,6 +29,8 @@ type Storage interface {
 
 	CreateGithub(context.Context, model.User, model.GitHubUser) (model.User, error)
 	GetGitHubUser(context.Context, uint64) (model.User, error)
+	UpdateGitHubUser(context.Context, model.User, model.GitHubUser) (model.User, error)
+	DeleteGitHubUser(context.Context, model.User) error
 }
 
 func Flags(fs *flag.FlagSet, prefix string, overrides ...flags.Override) *Config {
@@ -34,15 +40,26 @@ func Flags(fs *flag.FlagSet, prefix string, overrides ...flags.Override) *Config
 	flags.New("ClientSecret", "Client Secret").Prefix(prefix).DocPrefix("github").StringVar(fs, &config.clientSecret, "", overrides)
 	flags.New("RedirectURL", "URL used for redirection").Prefix(prefix).DocPrefix("github").StringVar(fs, &config.redirectURL, "http://127.0.0.1:1080/oauth/github/callback", overrides)
 	flags.New("OnSuccessPath", "Path for redirecting on success").Prefix(prefix).DocPrefix("github").StringVar(fs, &config.onSuccessPath, "/", overrides)
+	flags.New("Orgs", "Organizations allowed to login").Prefix(prefix).DocPrefix("github").StringSliceVar(fs, &config.orgs, nil, overrides)
+	flags.New("Teams", "Teams allowed to login, in the form 'org/team-slug'").Prefix(prefix).DocPrefix("github").StringSliceVar(fs, &config.teams, nil, overrides)
 
 	return &config
 }
 
-func New(config *Config, cache oauth.Cache, storage Storage, linkHandler oauth.LinkHandler, renderer *renderer.Service, cookie cookie.Service[model.OAuthClaim]) oauth.Service[model.GitHubUser, uint64] {
-	return oauth.New("github", "https://api.github.com/user", config.onSuccessPath, oauth2.Config{
+func New(config *Config, cache oauth.Cache, storage Storage, linkHandler oauth.LinkHandler, renderer *renderer.Service, cookie cookie.Service[model.OAuthClaim], redirection redirect.Service) oauth.Service[model.GitHubUser, uint64] {
+	var scopes []string
+	var options []oauth.Option[model.GitHubUser, uint64]
+
+	if len(config.orgs) != 0 || len(config.teams) != 0 {
+		scopes = append(scopes, "read:org")
+		options = append(options, oauth.WithAccessHandler[model.GitHubUser](membership(apiURL, config.orgs, config.teams)))
+	}
+
+	return oauth.New("github", apiURL+"/user", config.onSuccessPath, oauth2.Config{
 		ClientID:     config.clientID,
 		ClientSecret: config.clientSecret,
 		Endpoint:     github.Endpoint,
 		RedirectURL:  config.redirectURL,
-	}, cache, storage, linkHandler, storage.CreateGithub, storage.GetGitHubUser, renderer, cookie)
+		Scopes:       scopes,
+	}, cache, storage, linkHandler, storage.CreateGithub, storage.GetGitHubUser, storage.UpdateGitHubUser, storage.DeleteGitHubUser, revoke(fmt.Sprintf(grantURL, config.clientID), config.clientID, config.clientSecret), renderer, cookie, redirection, options...)
 }
@@ -0,0 +1,145 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/ViBiOh/auth/v3/pkg/model"
+	"github.com/ViBiOh/auth/v3/pkg/provider/oauth"
+	"github.com/ViBiOh/httputils/v4/pkg/httpjson"
+)
+
+const (
+	apiURL       = "https://api.github.com"
+	teamsPerPage = 100
+)
+
+type orgMembership struct {
+	State string `json:"state"`
+}
+
+type team struct {
+	Slug         string `json:"slug"`
+	Organization struct {
+		Login string `json:"login"`
+	} `json:"organization"`
+}
+
+var errNotMember = errors.New("not a member")
+
+// membership grants access to members of any of the organizations or any of the teams
+func membership(apiURL string, orgs, teams []string) oauth.AccessHandler {
+	return func(ctx context.Context, client *http.Client) error {
+		if len(orgs) != 0 {
+			if err := checkOrgs(ctx, client, apiURL, orgs); !errors.Is(err, errNotMember) {
+				return err
+			}
+		}
+
+		if len(teams) != 0 {
+			if err := checkTeams(ctx, client, apiURL, teams); !errors.Is(err, errNotMember) {
+				return err
+			}
+		}
+
+		return fmt.Errorf("not a member of the allowed GitHub %s: %w", allowed(orgs, teams), model.ErrForbidden)
+	}
+}
+
+func allowed(orgs, teams []string) string {
+	var parts []string
+
+	if len(orgs) != 0 {
+		parts = append(parts, "organizations `"+strings.Join(orgs, ", ")+"`")
+	}
+
+	if len(teams) != 0 {
+		parts = append(parts, "teams `"+strings.Join(teams, ", ")+"`")
+	}
+
+	return strings.Join(parts, " or ")
+}
+
+func checkOrgs(ctx context.Context, client *http.Client, apiURL string, orgs []string) error {
+	for _, org := range orgs {
+		resp, err := get(ctx, client, apiURL+"/user/memberships/orgs/"+url.PathEscape(org))
+		if err != nil {
+			return fmt.Errorf("get membership of `%s`: %w", org, err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			_ = resp.Body.Close()
+			continue
+		}
+
+		membership, err := httpjson.Read[orgMembership](resp)
+		if err != nil {
+			return fmt.Errorf("read membership of `%s`: %w", org, err)
+		}
+
+		if membership.State == "active" {
+			return nil
+		}
+	}
+
+	return errNotMember
+}
+
+func checkTeams(ctx context.Context, client *http.Client, apiURL string, teams []string) error {
+	for page := 1; ; page++ {
+		resp, err := get(ctx, client, fmt.Sprintf("%s/user/teams?per_page=%d&page=%d", apiURL, teamsPerPage, page))
+		if err != nil {
+			return fmt.Errorf("get teams: %w", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			_ = resp.Body.Close()
+			return fmt.Errorf("get teams: unexpected status %d", resp.StatusCode)
+		}
+
+		userTeams, err := httpjson.Read[[]team](resp)
+		if err != nil {
+			return fmt.Errorf("read teams: %w", err)
+		}
+
+		for _, userTeam := range userTeams {
+			if slices.ContainsFunc(teams, func(allowed string) bool {
+				return strings.EqualFold(allowed, userTeam.Organization.Login+"/"+userTeam.Slug)
+			}) {
+				return nil
+			}
+		}
+
+		if len(userTeams) < teamsPerPage {
+			break
+		}
+	}
+
+	return errNotMember
+}
+
+func get(ctx context.Context, client *http.Client, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		_ = resp.Body.Close()
+		return nil, errors.New("unauthorized")
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,89 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ViBiOh/auth/v3/pkg/model"
+)
+
+func TestMembership(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/user/memberships/orgs/vibioh":
+			_, _ = w.Write([]byte(`{"state":"active"}`))
+		case "/user/memberships/orgs/pending":
+			_, _ = w.Write([]byte(`{"state":"pending"}`))
+		case "/user/teams":
+			if r.URL.Query().Get("page") != "1" {
+				_, _ = w.Write([]byte(`[]`))
+				return
+			}
+
+			_, _ = fmt.Fprint(w, `[{"slug":"core","organization":{"login":"ViBiOh"}}]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	cases := map[string]struct {
+		orgs    []string
+		teams   []string
+		wantErr error
+	}{
+		"org member": {
+			[]string{"unknown", "vibioh"},
+			nil,
+			nil,
+		},
+		"org pending": {
+			[]string{"pending"},
+			nil,
+			model.ErrForbidden,
+		},
+		"org not member": {
+			[]string{"unknown"},
+			nil,
+			model.ErrForbidden,
+		},
+		"team member": {
+			[]string{"unknown"},
+			[]string{"vibioh/core"},
+			nil,
+		},
+		"org member without team": {
+			[]string{"vibioh"},
+			[]string{"vibioh/admin"},
+			nil,
+		},
+		"team not member": {
+			[]string{"unknown"},
+			[]string{"vibioh/admin"},
+			model.ErrForbidden,
+		},
+		"teams only": {
+			nil,
+			[]string{"vibioh/admin"},
+			model.ErrForbidden,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			gotErr := membership(server.URL, testCase.orgs, testCase.teams)(context.Background(), server.Client())
+
+			if !errors.Is(gotErr, testCase.wantErr) {
+				t.Errorf("membership() = `%s`, want `%s`", gotErr, testCase.wantErr)
+			}
+		})
+	}
+}
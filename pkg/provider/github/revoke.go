@@ -0,0 +1,45 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ViBiOh/auth/v3/pkg/provider/oauth"
+	"golang.org/x/oauth2"
+)
+
+const grantURL = "https://api.github.com/applications/%s/grant"
+
+func revoke(endpoint, clientID, clientSecret string) oauth.RevokeHandler {
+	return func(ctx context.Context, token *oauth2.Token) error {
+		payload, err := json.Marshal(map[string]string{"access_token": token.AccessToken})
+		if err != nil {
+			return fmt.Errorf("marshal: %w", err)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodDelete, endpoint, bytes.NewReader(payload))
+		if err != nil {
+			return fmt.Errorf("create request: %w", err)
+		}
+
+		req.SetBasicAuth(clientID, clientSecret)
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("delete grant: %w", err)
+		}
+
+		_ = resp.Body.Close()
+
+		if resp.StatusCode != http.StatusNoContent {
+			return fmt.Errorf("delete grant: unexpected status %d", resp.StatusCode)
+		}
+
+		return nil
+	}
+}
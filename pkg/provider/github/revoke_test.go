@@ -0,0 +1,66 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestRevoke(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientID, clientSecret, ok := r.BasicAuth()
+		if r.Method != http.MethodDelete || !ok || clientID != "client" || clientSecret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var payload map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || payload["access_token"] != "valid" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	cases := map[string]struct {
+		clientSecret string
+		token        string
+		wantErr      bool
+	}{
+		"valid": {
+			"secret",
+			"valid",
+			false,
+		},
+		"invalid credentials": {
+			"wrong",
+			"valid",
+			true,
+		},
+		"unknown token": {
+			"secret",
+			"unknown",
+			true,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			gotErr := revoke(server.URL, "client", testCase.clientSecret)(context.Background(), &oauth2.Token{AccessToken: testCase.token})
+
+			if (gotErr != nil) != testCase.wantErr {
+				t.Errorf("revoke() = `%s`, want error %t", gotErr, testCase.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package google
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ViBiOh/auth/v3/pkg/model"
+	"github.com/ViBiOh/auth/v3/pkg/provider/oauth"
+)
+
+func hostedDomain(domains []string) oauth.ValidateHandler[model.GoogleUser, string] {
+	return func(_ context.Context, user model.GoogleUser) error {
+		if len(user.HostedDomain) == 0 {
+			return fmt.Errorf("not a Google Workspace account: %w", model.ErrForbidden)
+		}
+
+		if !user.EmailVerified {
+			return fmt.Errorf("email not verified: %w", model.ErrForbidden)
+		}
+
+		if !slices.ContainsFunc(domains, func(domain string) bool {
+			return strings.EqualFold(domain, user.HostedDomain)
+		}) {
+			return fmt.Errorf("hosted domain `%s` not allowed: %w", user.HostedDomain, model.ErrForbidden)
+		}
+
+		return nil
+	}
+}
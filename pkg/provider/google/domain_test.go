@@ -0,0 +1,47 @@
+package google
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ViBiOh/auth/v3/pkg/model"
+)
+
+func TestHostedDomain(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		user    model.GoogleUser
+		wantErr error
+	}{
+		"allowed": {
+			model.GoogleUser{Sub: "1", Email: "bob@vibioh.fr", HostedDomain: "ViBiOh.fr", EmailVerified: true},
+			nil,
+		},
+		"personal account": {
+			model.GoogleUser{Sub: "1", Email: "bob@gmail.com", EmailVerified: true},
+			model.ErrForbidden,
+		},
+		"unverified email": {
+			model.GoogleUser{Sub: "1", Email: "bob@vibioh.fr", HostedDomain: "vibioh.fr"},
+			model.ErrForbidden,
+		},
+		"other domain": {
+			model.GoogleUser{Sub: "1", Email: "bob@example.com", HostedDomain: "example.com", EmailVerified: true},
+			model.ErrForbidden,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			gotErr := hostedDomain([]string{"vibioh.fr"})(context.Background(), testCase.user)
+
+			if !errors.Is(gotErr, testCase.wantErr) {
+				t.Errorf("hostedDomain() = `%s`, want `%s`", gotErr, testCase.wantErr)
+			}
+		})
+	}
+}
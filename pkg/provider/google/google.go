@@ -7,17 +7,22 @@ import (
 	"github.com/ViBiOh/auth/v3/pkg/cookie"
 	"github.com/ViBiOh/auth/v3/pkg/model"
 	"github.com/ViBiOh/auth/v3/pkg/provider/oauth"
+	"github.com/ViBiOh/auth/v3/pkg/redirect"
 	"github.com/ViBiOh/flags"
 	"github.com/ViBiOh/httputils/v4/pkg/renderer"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 )
 
+const revokeURL = "https://oauth2.googleapis.com/revoke"
+
 type Config struct {
 	clientID      string
 	clientSecret  string
 	redirectURL   string
 	onSuccessPath string
+	hostedDomains []string
+	email         bool
 }
 
 type Storage interface {
@@ -25,6 +30,8 @@ type Storage interface {
 
 	CreateGoogle(context.Context, model.User, model.GoogleUser) (model.User, error)
 	GetGoogleUser(context.Context, string) (model.User, error)
+	UpdateGoogleUser(context.Context, model.User, model.GoogleUser) (model.User, error)
+	DeleteGoogleUser(context.Context, model.User) error
 }
 
 func Flags(fs *flag.FlagSet, prefix string, overrides ...flags.Override) *Config {
@@ -34,16 +41,29 @@ func Flags(fs *flag.FlagSet, prefix string, overrides ...flags.Override) *Config
 	flags.New("ClientSecret", "Client Secret").Prefix(prefix).DocPrefix("google").StringVar(fs, &config.clientSecret, "", overrides)
 	flags.New("RedirectURL", "URL used for redirection").Prefix(prefix).DocPrefix("google").StringVar(fs, &config.redirectURL, "http://127.0.0.1:1080/oauth/google/callback", overrides)
 	flags.New("OnSuccessPath", "Path for redirecting on success").Prefix(prefix).DocPrefix("google").StringVar(fs, &config.onSuccessPath, "/", overrides)
+	flags.New("Email", "Request email scope and store verified email").Prefix(prefix).DocPrefix("google").BoolVar(fs, &config.email, false, overrides)
+	flags.New("HostedDomains", "Google Workspace domains allowed to login").Prefix(prefix).DocPrefix("google").StringSliceVar(fs, &config.hostedDomains, nil, overrides)
 
 	return &config
 }
 
-func New(config *Config, cache oauth.Cache, storage Storage, linkHandler oauth.LinkHandler, renderer *renderer.Service, cookie cookie.Service[model.OAuthClaim]) oauth.Service[model.GoogleUser, string] {
+func New(config *Config, cache oauth.Cache, storage Storage, linkHandler oauth.LinkHandler, renderer *renderer.Service, cookie cookie.Service[model.OAuthClaim], redirection redirect.Service) oauth.Service[model.GoogleUser, string] {
+	scopes := []string{"openid", "profile"}
+	var options []oauth.Option[model.GoogleUser, string]
+
+	if config.email || len(config.hostedDomains) != 0 {
+		scopes = append(scopes, "email")
+	}
+
+	if len(config.hostedDomains) != 0 {
+		options = append(options, oauth.WithValidateHandler(hostedDomain(config.hostedDomains)))
+	}
+
 	return oauth.New("google", "https://www.googleapis.com/oauth2/v3/userinfo", config.onSuccessPath, oauth2.Config{
 		ClientID:     config.clientID,
 		ClientSecret: config.clientSecret,
 		Endpoint:     google.Endpoint,
 		RedirectURL:  config.redirectURL,
-		Scopes:       []string{"openid", "profile"},
-	}, cache, storage, linkHandler, storage.CreateGoogle, storage.GetGoogleUser, renderer, cookie)
+		Scopes:       scopes,
+	}, cache, storage, linkHandler, storage.CreateGoogle, storage.GetGoogleUser, storage.UpdateGoogleUser, storage.DeleteGoogleUser, oauth.TokenRevoker(revokeURL, "", ""), renderer, cookie, redirection, options...)
 }
@@ -0,0 +1,384 @@
+package magiclink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/ViBiOh/auth/v3/internal/secret"
+	"github.com/ViBiOh/auth/v3/pkg/cookie"
+	"github.com/ViBiOh/auth/v3/pkg/mailer"
+	"github.com/ViBiOh/auth/v3/pkg/model"
+	"github.com/ViBiOh/auth/v3/pkg/provider/oauth"
+	"github.com/ViBiOh/auth/v3/pkg/redirect"
+	"github.com/ViBiOh/flags"
+	httpModel "github.com/ViBiOh/httputils/v4/pkg/model"
+	"github.com/ViBiOh/httputils/v4/pkg/renderer"
+)
+
+const (
+	name        = "email"
+	cookieName  = "_auth"
+	tokenPrefix = "auth:magiclink:"
+)
+
+var _ model.Authentication = Service{}
+
+type Storage interface {
+	oauth.Storage
+
+	CreateEmail(context.Context, model.User, string) (model.User, error)
+	GetEmailUser(context.Context, string) (model.User, error)
+}
+
+type Config struct {
+	publicURL     string
+	onSuccessPath string
+	ttl           time.Duration
+}
+
+type Service struct {
+	cache         oauth.Cache
+	storage       Storage
+	mailer        mailer.Mailer
+	template      mailer.Template
+	linkHandler   oauth.LinkHandler
+	renderer      *renderer.Service
+	cookie        cookie.Service[model.OAuthClaim]
+	redirection   redirect.Service
+	publicURL     string
+	sendPath      string
+	callbackPath  string
+	onSuccessPath string
+	ttl           time.Duration
+}
+
+type payload struct {
+	Email        string `json:"email"`
+	Registration string `json:"registration,omitempty"`
+	Redirection  string `json:"redirection,omitempty"`
+}
+
+func Flags(fs *flag.FlagSet, prefix string, overrides ...flags.Override) *Config {
+	var config Config
+
+	flags.New("PublicURL", "Public URL of the callback").Prefix(prefix).DocPrefix("magiclink").StringVar(fs, &config.publicURL, "http://127.0.0.1:1080/oauth/email/callback", overrides)
+	flags.New("OnSuccessPath", "Path for redirecting on success").Prefix(prefix).DocPrefix("magiclink").StringVar(fs, &config.onSuccessPath, "/", overrides)
+	flags.New("TTL", "Validity of the link").Prefix(prefix).DocPrefix("magiclink").DurationVar(fs, &config.ttl, time.Minute*15, overrides)
+
+	return &config
+}
+
+func New(config *Config, cache oauth.Cache, storage Storage, sender mailer.Mailer, linkHandler oauth.LinkHandler, renderer *renderer.Service, cookie cookie.Service[model.OAuthClaim], redirection redirect.Service) (Service, error) {
+	callbackURL, err := url.Parse(config.publicURL)
+	if err != nil {
+		return Service{}, fmt.Errorf("parse public url: %w", err)
+	}
+
+	return Service{
+		cache:         cache,
+		storage:       storage,
+		mailer:        sender,
+		template:      mailer.NewTemplate(renderer, "magiclink-email"),
+		linkHandler:   linkHandler,
+		renderer:      renderer,
+		cookie:        cookie,
+		redirection:   redirection,
+		publicURL:     config.publicURL,
+		sendPath:      path.Dir(callbackURL.Path) + "/send",
+		callbackPath:  callbackURL.Path,
+		onSuccessPath: config.onSuccessPath,
+		ttl:           config.ttl,
+	}, nil
+}
+
+func (s Service) Name() string {
+	return name
+}
+
+func (s Service) RegisterPath(prefix string) string {
+	return prefix
+}
+
+func (s Service) Mux(prefix string, mux *http.ServeMux) {
+	mux.HandleFunc(prefix, s.Form)
+	mux.HandleFunc(prefix+"/send", s.Send)
+	mux.HandleFunc("GET "+prefix+"/callback", s.Confirm)
+	mux.HandleFunc("POST "+prefix+"/callback", s.Callback)
+	mux.HandleFunc("POST "+prefix+"/logout", s.Logout)
+}
+
+func (s Service) GetUser(_ context.Context, _ http.ResponseWriter, r *http.Request) (model.User, error) {
+	claim, err := s.cookie.Get(r, cookieName)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	if len(claim.Content.User.ID) == 0 {
+		return model.User{}, errors.New("no content")
+	}
+
+	if claim.Content.Provider != name {
+		return model.User{}, oauth.ErrOtherProvider
+	}
+
+	return claim.Content.User, nil
+}
+
+func (s Service) OnUnauthorized(w http.ResponseWriter, r *http.Request, _ error) {
+	s.renderForm(w, r, "", r.URL.String(), nil)
+}
+
+func (s Service) Logout(w http.ResponseWriter, r *http.Request) {
+	s.cookie.Clear(w, cookieName)
+
+	s.renderer.Serve(w, r, renderer.NewPage("auth", http.StatusOK, map[string]any{
+		"Redirect": "/",
+		"Message":  renderer.NewSuccessMessage("Logout success!"),
+	}))
+}
+
+func (s Service) Form(w http.ResponseWriter, r *http.Request) {
+	s.renderForm(w, r, r.URL.Query().Get("registration"), r.URL.Query().Get("redirect"), nil)
+}
+
+func (s Service) renderForm(w http.ResponseWriter, r *http.Request, registration, redirection string, message *renderer.Message) {
+	content := map[string]any{
+		"Action":       s.sendPath,
+		"Registration": registration,
+		"Redirect":     s.redirection.Sanitize(redirection, s.onSuccessPath),
+	}
+
+	if message != nil {
+		content["Message"] = *message
+	}
+
+	s.renderer.Serve(w, r, renderer.NewPage("magiclink", http.StatusOK, content))
+}
+
+func (s Service) Send(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := r.Context()
+
+	content := payload{
+		Registration: r.FormValue("registration"),
+		Redirection:  s.redirection.Sanitize(r.FormValue("redirect"), s.onSuccessPath),
+	}
+
+	address, err := mail.ParseAddress(r.FormValue("email"))
+	if err != nil {
+		message := renderer.NewErrorMessage("Invalid email address")
+		s.renderForm(w, r, content.Registration, content.Redirection, &message)
+		return
+	}
+
+	content.Email = strings.ToLower(address.Address)
+
+	if err := s.check(ctx, content); err != nil {
+		if errors.Is(err, model.ErrUnknownUser) {
+			message := renderer.NewErrorMessage("Unknown registration code or already used")
+			s.renderForm(w, r, "", content.Redirection, &message)
+			return
+		}
+
+		if !errors.Is(err, errNoAccount) {
+			s.renderer.Error(w, r, nil, err)
+			return
+		}
+	} else {
+		// sent in background so the response time doesn't disclose whether the account exists
+		go func(ctx context.Context) {
+			if err := s.send(ctx, content); err != nil {
+				slog.LogAttrs(ctx, slog.LevelError, "unable to send magic link", slog.Any("error", err))
+			}
+		}(context.WithoutCancel(ctx))
+	}
+
+	message := renderer.NewSuccessMessage("If an account matches this address, a sign-in link has been sent")
+	s.renderForm(w, r, content.Registration, content.Redirection, &message)
+}
+
+var errNoAccount = errors.New("no account for email")
+
+func (s Service) check(ctx context.Context, content payload) error {
+	if len(content.Registration) != 0 {
+		invitation, err := s.storage.GetInviteByToken(ctx, content.Registration)
+		if err != nil {
+			return err
+		}
+
+		if !invitation.AllowProvider(name) {
+			return model.ErrUnknownUser
+		}
+
+		return nil
+	}
+
+	if _, err := s.storage.GetEmailUser(ctx, content.Email); err != nil {
+		if errors.Is(err, model.ErrUnknownUser) {
+			return errNoAccount
+		}
+
+		return fmt.Errorf("get user: %w", err)
+	}
+
+	return nil
+}
+
+func (s Service) send(ctx context.Context, content payload) error {
+	token, err := secret.New()
+	if err != nil {
+		return err
+	}
+
+	rawPayload, err := json.Marshal(content)
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+
+	if err := s.cache.Store(ctx, tokenPrefix+secret.Hash(token), rawPayload, s.ttl); err != nil {
+		return fmt.Errorf("store token: %w", err)
+	}
+
+	email, err := s.template.Email(map[string]any{
+		"URL": s.publicURL + "?token=" + url.QueryEscape(token),
+		"TTL": s.ttl,
+	}, content.Email)
+	if err != nil {
+		return fmt.Errorf("render email: %w", err)
+	}
+
+	if err := s.mailer.Send(ctx, email); err != nil {
+		return fmt.Errorf("send email: %w", err)
+	}
+
+	return nil
+}
+
+// Confirm doesn't consume the token, mail scanners following links on GET would use it up
+func (s Service) Confirm(w http.ResponseWriter, r *http.Request) {
+	s.renderer.Serve(w, r, renderer.NewPage("magiclink-confirm", http.StatusOK, map[string]any{
+		"Action": s.callbackPath,
+		"Token":  r.URL.Query().Get("token"),
+	}))
+}
+
+func (s Service) Callback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	content, err := s.consume(ctx, r.FormValue("token"))
+	if err != nil {
+		s.renderer.Error(w, r, nil, err)
+		return
+	}
+
+	redirect := s.redirection.Sanitize(content.Redirection, s.onSuccessPath)
+
+	user, err := s.storage.GetEmailUser(ctx, content.Email)
+	if err != nil && !errors.Is(err, model.ErrUnknownUser) {
+		s.renderer.Error(w, r, nil, fmt.Errorf("get user: %w", err))
+		return
+	}
+
+	if len(content.Registration) != 0 {
+		user, err = s.register(ctx, content, user)
+	}
+
+	if err != nil {
+		if errors.Is(err, model.ErrUnknownUser) {
+			message := renderer.NewErrorMessage("Unknown account or registration code already used")
+			s.renderForm(w, r, "", redirect, &message)
+			return
+		}
+
+		s.renderer.Error(w, r, nil, fmt.Errorf("register: %w", err))
+		return
+	}
+
+	if identities, err := s.storage.ListIdentities(ctx, user); err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "unable to list identities", slog.String("id", user.ID), slog.Any("error", err))
+	} else {
+		user.Identities = identities
+	}
+
+	if !s.cookie.Set(ctx, w, cookieName, model.OAuthClaim{Provider: name, User: user}) {
+		return
+	}
+
+	s.renderer.Serve(w, r, renderer.NewPage("auth", http.StatusOK, map[string]any{
+		"Redirect": redirect,
+		"Message":  renderer.NewSuccessMessage("Login success!"),
+	}))
+}
+
+func (s Service) register(ctx context.Context, content payload, user model.User) (model.User, error) {
+	invitation, err := s.storage.GetInviteByToken(ctx, content.Registration)
+	if err != nil {
+		return user, err
+	}
+
+	if !invitation.AllowProvider(name) {
+		return user, model.ErrUnknownUser
+	}
+
+	return user, s.storage.DoAtomic(ctx, func(ctx context.Context) (err error) {
+		user, err = oauth.Register(ctx, s.storage, s.linkHandler, invitation, user, func(ctx context.Context, invite model.User) (model.User, error) {
+			return s.storage.CreateEmail(ctx, invite, content.Email)
+		})
+
+		return err
+	})
+}
+
+func (s Service) consume(ctx context.Context, token string) (payload, error) {
+	var content payload
+
+	if len(token) == 0 {
+		return content, httpModel.WrapInvalid(errors.New("token is required"))
+	}
+
+	key := tokenPrefix + secret.Hash(token)
+
+	acquired, err := s.cache.Exclusive(ctx, key+":consume", time.Second*30, func(ctx context.Context) error {
+		rawPayload, err := s.cache.Load(ctx, key)
+		if err != nil {
+			return fmt.Errorf("load token: %w", err)
+		}
+
+		if len(rawPayload) == 0 {
+			return httpModel.WrapNotFound(errors.New("link expired or already used"))
+		}
+
+		if err := s.cache.Delete(ctx, key); err != nil {
+			return fmt.Errorf("delete token: %w", err)
+		}
+
+		if err := json.Unmarshal(rawPayload, &content); err != nil {
+			return fmt.Errorf("unmarshal token: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return content, err
+	}
+
+	if !acquired {
+		return content, httpModel.WrapForbidden(errors.New("link already in use"))
+	}
+
+	return content, nil
+}
@@ -0,0 +1,118 @@
+package magiclink
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ViBiOh/auth/v3/internal/fake"
+	"github.com/ViBiOh/auth/v3/internal/secret"
+	"github.com/ViBiOh/auth/v3/pkg/cookie"
+	"github.com/ViBiOh/auth/v3/pkg/mailer"
+	"github.com/ViBiOh/auth/v3/pkg/model"
+	"github.com/ViBiOh/auth/v3/pkg/redirect"
+	httpModel "github.com/ViBiOh/httputils/v4/pkg/model"
+)
+
+const emailTemplate = `
+{{ define "magiclink-email-subject" }}Sign in{{ end }}
+{{ define "magiclink-email-text" }}{{ .URL }}{{ end }}
+{{ define "magiclink-email-html" }}<a href="{{ .URL }}">Sign in</a>{{ end }}
+`
+
+func TestSendAndConsume(t *testing.T) {
+	t.Parallel()
+
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	config := Flags(fs, "")
+
+	if err := fs.Parse(nil); err != nil {
+		t.Fatalf("parse flags: %s", err)
+	}
+
+	cache := fake.NewCache()
+	sender := fake.NewMailer(nil)
+
+	instance, err := New(config, cache, nil, sender, nil, nil, cookie.Service[model.OAuthClaim]{}, redirect.Service{})
+	if err != nil {
+		t.Fatalf("New() = `%s`", err)
+	}
+
+	if instance.sendPath != "/oauth/email/send" {
+		t.Errorf("New() sendPath = `%s`, want `/oauth/email/send`", instance.sendPath)
+	}
+
+	instance.template = mailer.NewTemplate(fake.NewRenderer(emailTemplate), "magiclink-email")
+
+	if err := instance.send(context.Background(), payload{Email: "bob@vibioh.fr", Redirection: "/"}); err != nil {
+		t.Fatalf("send() = `%s`", err)
+	}
+
+	emails := sender.Emails()
+	if len(emails) != 1 || len(emails[0].To) != 1 || emails[0].To[0] != "bob@vibioh.fr" {
+		t.Fatalf("send() = %+v, want one email to bob@vibioh.fr", emails)
+	}
+
+	start := strings.Index(emails[0].Text, "http://")
+	if start == -1 {
+		t.Fatalf("send() = `%s`, want a link", emails[0].Text)
+	}
+
+	link, err := url.Parse(strings.Fields(emails[0].Text[start:])[0])
+	if err != nil {
+		t.Fatalf("parse link: %s", err)
+	}
+
+	token := link.Query().Get("token")
+
+	for _, key := range cache.Keys() {
+		if strings.Contains(key, token) {
+			t.Errorf("send() stored clear token in `%s`", key)
+		}
+	}
+
+	content, err := instance.consume(context.Background(), token)
+	if err != nil {
+		t.Fatalf("consume() = `%s`", err)
+	}
+
+	if content.Email != "bob@vibioh.fr" {
+		t.Errorf("consume() = %+v, want bob@vibioh.fr", content)
+	}
+
+	if _, err := instance.consume(context.Background(), token); !errors.Is(err, httpModel.ErrNotFound) {
+		t.Errorf("consume() = `%s`, want not found", err)
+	}
+}
+
+func TestConsumeInUse(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cache := fake.NewCache()
+	instance := Service{cache: cache}
+
+	key := tokenPrefix + secret.Hash("secret")
+	if err := cache.Store(ctx, key, []byte(`{"email":"bob@vibioh.fr"}`), time.Minute); err != nil {
+		t.Fatalf("store token: %s", err)
+	}
+
+	if _, err := cache.Exclusive(ctx, key+":consume", time.Second, func(ctx context.Context) error {
+		_, err := instance.consume(ctx, "secret")
+		return err
+	}); !errors.Is(err, httpModel.ErrForbidden) {
+		t.Errorf("consume() in use = `%v`, want forbidden", err)
+	}
+
+	if content, err := instance.consume(ctx, "secret"); err != nil || content.Email != "bob@vibioh.fr" {
+		t.Errorf("consume() = (%+v, `%v`), want bob@vibioh.fr", content, err)
+	}
+
+	if _, err := instance.consume(ctx, "secret"); !errors.Is(err, httpModel.ErrNotFound) {
+		t.Errorf("consume() replay = `%v`, want not found", err)
+	}
+}
@@ -2,16 +2,30 @@ package oauth
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"slices"
 	"time"
 
+	"github.com/ViBiOh/auth/v3/internal/secret"
 	"github.com/ViBiOh/auth/v3/pkg/model"
+	"golang.org/x/oauth2"
 )
 
-const updateCheckTTL = time.Hour * 2
+const (
+	updateCheckTTL = time.Hour * 2
+	accessCheckTTL = time.Minute * 15
+	refreshedTTL   = time.Second * 30
+	refreshTimeout = time.Second * 10
+	refreshPoll    = time.Millisecond * 100
+)
+
+var (
+	ErrRevokedGrant  = errors.New("grant revoked by provider")
+	ErrOtherProvider = errors.New("token issued by another provider")
+)
 
 var updateMethods = []string{
 	http.MethodPost,
@@ -30,32 +44,191 @@ func (s Service[T, I]) GetUser(ctx context.Context, w http.ResponseWriter, r *ht
 		return model.User{}, errors.New("no content")
 	}
 
-	if slices.Contains(updateMethods, r.Method) {
-		ctx := r.Context()
-		key := updateCacheKey + claim.Content.User.ID
+	if len(claim.Content.Provider) != 0 && claim.Content.Provider != s.name {
+		return model.User{}, ErrOtherProvider
+	}
 
-		if content, _ := s.cache.Load(ctx, key); content == nil {
-			initialToken := claim.Content.Token.AccessToken
+	token, err := s.refreshToken(ctx, claim.Content.User, claim.Content.Token)
+	if err != nil {
+		if errors.Is(err, ErrRevokedGrant) {
+			s.cookie.Clear(w, cookieName)
+		}
 
-			_, err := s.config.Client(ctx, claim.Content.Token).Get(s.getURL)
-			if err != nil {
-				return model.User{}, fmt.Errorf("refresh user: %w", err)
+		return model.User{}, fmt.Errorf("refresh token: %w", err)
+	}
+
+	if s.accessHandler != nil || s.syncHandler != nil {
+		if err := s.checkAccess(ctx, claim.Content.User, token); err != nil {
+			if errors.Is(err, model.ErrForbidden) {
+				s.cookie.Clear(w, cookieName)
 			}
 
-			_ = s.cache.Store(ctx, key, time.Now(), updateCheckTTL)
+			return model.User{}, err
+		}
+	}
 
-			if initialToken != claim.Content.Token.AccessToken {
-				s.cookie.Set(ctx, w, cookieName, model.OAuthClaim{
-					Token: claim.Content.Token,
-					User:  claim.Content.User,
-				})
+	if slices.Contains(updateMethods, r.Method) {
+		if err := s.checkGrant(ctx, claim.Content.User, token); err != nil {
+			if errors.Is(err, ErrRevokedGrant) {
+				s.cookie.Clear(w, cookieName)
 			}
+
+			return model.User{}, err
 		}
 	}
 
+	if token != claim.Content.Token {
+		s.cookie.Set(ctx, w, cookieName, model.OAuthClaim{
+			Token:    token,
+			Provider: claim.Content.Provider,
+			User:     claim.Content.User,
+		})
+	}
+
 	return claim.Content.User, nil
 }
 
+// Refresh tokens are single-use for some providers: concurrent requests wait for the one refreshing
+func (s Service[T, I]) refreshToken(ctx context.Context, user model.User, token *oauth2.Token) (*oauth2.Token, error) {
+	if token == nil || token.Valid() {
+		return token, nil
+	}
+
+	if len(token.RefreshToken) == 0 {
+		return nil, errors.New("token expired without refresh token")
+	}
+
+	refreshedKey := s.refreshedKey(user, token)
+	lockKey := fmt.Sprintf(refreshCacheKey, s.name) + user.ID
+
+	ctx, cancel := context.WithTimeout(ctx, refreshTimeout)
+	defer cancel()
+
+	for {
+		if refreshed := s.loadRefreshed(ctx, refreshedKey); refreshed != nil {
+			return refreshed, nil
+		}
+
+		var refreshed *oauth2.Token
+
+		acquired, err := s.cache.Exclusive(ctx, lockKey, refreshTimeout, func(ctx context.Context) (err error) {
+			if refreshed = s.loadRefreshed(ctx, refreshedKey); refreshed != nil {
+				return nil
+			}
+
+			if refreshed, err = s.exchangeRefresh(ctx, token); err != nil {
+				return err
+			}
+
+			if payload, err := json.Marshal(refreshed); err == nil {
+				_ = s.cache.Store(ctx, refreshedKey, payload, refreshedTTL)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if acquired {
+			return refreshed, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("wait refresh: %w", ctx.Err())
+		case <-time.After(refreshPoll):
+		}
+	}
+}
+
+func (s Service[T, I]) refreshedKey(user model.User, token *oauth2.Token) string {
+	return fmt.Sprintf(refreshCacheKey, s.name) + user.ID + ":" + secret.Hash(token.RefreshToken)
+}
+
+func (s Service[T, I]) loadRefreshed(ctx context.Context, key string) *oauth2.Token {
+	content, err := s.cache.Load(ctx, key)
+	if err != nil || len(content) == 0 {
+		return nil
+	}
+
+	var token oauth2.Token
+	if err := json.Unmarshal(content, &token); err != nil {
+		return nil
+	}
+
+	return &token
+}
+
+func (s Service[T, I]) exchangeRefresh(ctx context.Context, token *oauth2.Token) (*oauth2.Token, error) {
+	refreshed, err := s.config.TokenSource(ctx, token).Token()
+	if err != nil {
+		var retrieveErr *oauth2.RetrieveError
+		if errors.As(err, &retrieveErr) && retrieveErr.ErrorCode == "invalid_grant" {
+			return nil, fmt.Errorf("%w: %w", ErrRevokedGrant, err)
+		}
+
+		return nil, err
+	}
+
+	return refreshed, nil
+}
+
+func (s Service[T, I]) checkGrant(ctx context.Context, user model.User, token *oauth2.Token) error {
+	key := fmt.Sprintf(updateCacheKey, s.name) + user.ID
+
+	if content, _ := s.cache.Load(ctx, key); content != nil {
+		return nil
+	}
+
+	resp, err := oauth2.NewClient(ctx, oauth2.StaticTokenSource(token)).Get(s.getURL)
+	if err != nil {
+		return fmt.Errorf("refresh user: %w", err)
+	}
+
+	_ = resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized, resp.StatusCode == http.StatusForbidden:
+		return ErrRevokedGrant
+
+	case resp.StatusCode < http.StatusOK, resp.StatusCode >= http.StatusMultipleChoices:
+		// provider unavailable, the grant is checked again on next update
+		return nil
+	}
+
+	_ = s.cache.Store(ctx, key, time.Now(), updateCheckTTL)
+
+	return nil
+}
+
+func (s Service[T, I]) checkAccess(ctx context.Context, user model.User, token *oauth2.Token) error {
+	key := fmt.Sprintf(accessCacheKey, s.name) + user.ID
+
+	if content, _ := s.cache.Load(ctx, key); content != nil {
+		return nil
+	}
+
+	ctx = withShared(ctx)
+	client := oauth2.NewClient(ctx, oauth2.StaticTokenSource(token))
+
+	if s.accessHandler != nil {
+		if err := s.accessHandler(ctx, client); err != nil {
+			return fmt.Errorf("check access: %w", err)
+		}
+	}
+
+	if s.syncHandler != nil {
+		if err := s.syncHandler(ctx, client, user); err != nil {
+			return fmt.Errorf("sync: %w", err)
+		}
+	}
+
+	_ = s.cache.Store(ctx, key, time.Now(), accessCheckTTL)
+
+	return nil
+}
+
 func (s Service[T, I]) OnUnauthorized(w http.ResponseWriter, r *http.Request, err error) {
-	s.redirect(w, r, "", r.URL.String())
+	s.redirect(w, r, State{Redirection: r.URL.String()})
 }
@@ -0,0 +1,316 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ViBiOh/auth/v3/internal/fake"
+	"github.com/ViBiOh/auth/v3/pkg/cookie"
+	"github.com/ViBiOh/auth/v3/pkg/model"
+	"github.com/ViBiOh/auth/v3/pkg/redirect"
+	"golang.org/x/oauth2"
+)
+
+var errAny = errors.New("any error")
+
+func newTestCookie(t *testing.T) cookie.Service[model.OAuthClaim] {
+	t.Helper()
+
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	config := cookie.Flags(fs, "")
+
+	if err := fs.Parse([]string{"-hmacSecret", "secret"}); err != nil {
+		t.Fatalf("parse cookie flags: %s", err)
+	}
+
+	return cookie.New[model.OAuthClaim](config)
+}
+
+func newTestRequest(t *testing.T, cookieService cookie.Service[model.OAuthClaim], method, provider string, token *oauth2.Token) *http.Request {
+	t.Helper()
+
+	writer := httptest.NewRecorder()
+	cookieService.Set(context.Background(), writer, cookieName, model.OAuthClaim{
+		Token:    token,
+		Provider: provider,
+		User:     model.User{ID: "1", Name: "vibioh", Kind: model.GitHub},
+	})
+
+	req := httptest.NewRequest(method, "/", nil)
+	for _, item := range writer.Result().Cookies() {
+		req.AddCookie(item)
+	}
+
+	return req
+}
+
+func TestGetUser(t *testing.T) {
+	t.Parallel()
+
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+
+		if r.Form.Get("refresh_token") == "unavailable" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		if r.Form.Get("refresh_token") != "valid" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"refreshed","token_type":"bearer","expires_in":3600}`))
+	}))
+	t.Cleanup(tokenServer.Close)
+
+	userServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(userServer.Close)
+
+	cookieService := newTestCookie(t)
+
+	cases := map[string]struct {
+		token       *oauth2.Token
+		method      string
+		provider    string
+		wantErr     error
+		wantCookie  bool
+		wantCleared bool
+	}{
+		"valid token": {
+			&oauth2.Token{AccessToken: "fresh", Expiry: time.Now().Add(time.Hour)},
+			http.MethodGet,
+			"github",
+			nil,
+			false,
+			false,
+		},
+		"other provider": {
+			&oauth2.Token{AccessToken: "stale", RefreshToken: "valid", Expiry: time.Now().Add(-time.Hour)},
+			http.MethodGet,
+			"google",
+			ErrOtherProvider,
+			false,
+			false,
+		},
+		"expired token": {
+			&oauth2.Token{AccessToken: "stale", RefreshToken: "valid", Expiry: time.Now().Add(-time.Hour)},
+			http.MethodGet,
+			"github",
+			nil,
+			true,
+			false,
+		},
+		"refresh failure": {
+			&oauth2.Token{AccessToken: "stale", RefreshToken: "revoked", Expiry: time.Now().Add(-time.Hour)},
+			http.MethodGet,
+			"github",
+			ErrRevokedGrant,
+			false,
+			true,
+		},
+		"provider unavailable": {
+			&oauth2.Token{AccessToken: "stale", RefreshToken: "unavailable", Expiry: time.Now().Add(-time.Hour)},
+			http.MethodGet,
+			"github",
+			errAny,
+			false,
+			false,
+		},
+		"revoked upstream": {
+			&oauth2.Token{AccessToken: "revoked", Expiry: time.Now().Add(time.Hour)},
+			http.MethodPost,
+			"",
+			ErrRevokedGrant,
+			false,
+			true,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			instance := New[model.GitHubUser, uint64]("github", userServer.URL, "/", oauth2.Config{
+				Endpoint: oauth2.Endpoint{TokenURL: tokenServer.URL},
+			}, fake.NewCache(), nil, nil, nil, nil, nil, nil, nil, nil, cookieService, redirect.Service{})
+
+			writer := httptest.NewRecorder()
+			_, gotErr := instance.GetUser(context.Background(), writer, newTestRequest(t, cookieService, testCase.method, testCase.provider, testCase.token))
+
+			if testCase.wantErr == errAny && gotErr == nil || testCase.wantErr != errAny && !errors.Is(gotErr, testCase.wantErr) {
+				t.Errorf("GetUser() = `%s`, want `%s`", gotErr, testCase.wantErr)
+			}
+
+			var gotCookie, gotCleared bool
+			for _, item := range writer.Result().Cookies() {
+				if item.Name != cookieName {
+					continue
+				}
+
+				if item.MaxAge < 0 {
+					gotCleared = true
+				} else {
+					gotCookie = true
+				}
+			}
+
+			if gotCookie != testCase.wantCookie || gotCleared != testCase.wantCleared {
+				t.Errorf("GetUser() cookie = (%t, %t), want (%t, %t)", gotCookie, gotCleared, testCase.wantCookie, testCase.wantCleared)
+			}
+		})
+	}
+}
+
+func TestRefreshTokenConcurrent(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if calls.Add(1) > 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+
+		time.Sleep(time.Millisecond * 200)
+		_, _ = w.Write([]byte(`{"access_token":"refreshed","refresh_token":"rotated","token_type":"bearer","expires_in":3600}`))
+	}))
+	t.Cleanup(tokenServer.Close)
+
+	instance := Service[model.GitHubUser, uint64]{
+		name:  "github",
+		cache: fake.NewCache(),
+		config: oauth2.Config{
+			Endpoint: oauth2.Endpoint{TokenURL: tokenServer.URL},
+		},
+	}
+
+	token := &oauth2.Token{AccessToken: "stale", RefreshToken: "single-use", Expiry: time.Now().Add(-time.Hour)}
+
+	var wg sync.WaitGroup
+
+	for range 5 {
+		wg.Go(func() {
+			refreshed, err := instance.refreshToken(context.Background(), model.User{ID: "1"}, token)
+			if err != nil {
+				t.Errorf("refreshToken() = `%s`", err)
+				return
+			}
+
+			if refreshed.AccessToken != "refreshed" {
+				t.Errorf("refreshToken() = `%s`, want `refreshed`", refreshed.AccessToken)
+			}
+		})
+	}
+
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("refreshToken() called provider %d times, want 1", got)
+	}
+}
+
+func TestCheckGrant(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		status     int
+		wantErr    error
+		wantCached bool
+	}{
+		"valid": {
+			http.StatusOK,
+			nil,
+			true,
+		},
+		"revoked": {
+			http.StatusUnauthorized,
+			ErrRevokedGrant,
+			false,
+		},
+		"unavailable": {
+			http.StatusBadGateway,
+			nil,
+			false,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			userServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(testCase.status)
+			}))
+			t.Cleanup(userServer.Close)
+
+			cache := fake.NewCache()
+			instance := Service[model.GitHubUser, uint64]{name: "github", getURL: userServer.URL, cache: cache}
+
+			gotErr := instance.checkGrant(context.Background(), model.User{ID: "1"}, &oauth2.Token{AccessToken: "fresh"})
+			if !errors.Is(gotErr, testCase.wantErr) {
+				t.Errorf("checkGrant() = `%s`, want `%s`", gotErr, testCase.wantErr)
+			}
+
+			content, _ := cache.Load(context.Background(), "auth:github:update:1")
+			if gotCached := content != nil; gotCached != testCase.wantCached {
+				t.Errorf("checkGrant() cached = %t, want %t", gotCached, testCase.wantCached)
+			}
+		})
+	}
+}
+
+func TestCheckAccessShared(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	fetch := func(ctx context.Context) error {
+		_, err := Shared(ctx, "member", func() (string, error) {
+			calls.Add(1)
+			return "member", nil
+		})
+
+		return err
+	}
+
+	instance := Service[model.GitHubUser, uint64]{
+		name:  "github",
+		cache: fake.NewCache(),
+		accessHandler: func(ctx context.Context, _ *http.Client) error {
+			return fetch(ctx)
+		},
+		syncHandler: func(ctx context.Context, _ *http.Client, _ model.User) error {
+			return fetch(ctx)
+		},
+	}
+
+	if err := instance.checkAccess(context.Background(), model.User{ID: "1"}, &oauth2.Token{AccessToken: "fresh"}); err != nil {
+		t.Fatalf("checkAccess() = `%s`", err)
+	}
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("checkAccess() fetched %d times, want 1", got)
+	}
+}
@@ -10,6 +10,15 @@ type State struct {
 	Verifier     string `json:"verifier"`
 	Registration string `json:"registration"`
 	Redirection  string `json:"redirect"`
+	Link         string `json:"link,omitempty"`
+}
+
+type StateClaim struct {
+	State string `json:"state"`
+}
+
+func (sc StateClaim) GetSubject() string {
+	return sc.State
 }
 
 type AuthClaims struct {
@@ -7,10 +7,12 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/ViBiOh/auth/v3/pkg/cookie"
 	"github.com/ViBiOh/auth/v3/pkg/model"
+	"github.com/ViBiOh/auth/v3/pkg/redirect"
 	"github.com/ViBiOh/httputils/v4/pkg/httpjson"
 	"github.com/ViBiOh/httputils/v4/pkg/id"
 	httpModel "github.com/ViBiOh/httputils/v4/pkg/model"
@@ -21,7 +23,11 @@ import (
 const (
 	verifierCacheKey = "auth:%s:verifier:"
 	updateCacheKey   = "auth:%s:update:"
+	accessCacheKey   = "auth:%s:access:"
+	refreshCacheKey  = "auth:%s:refresh:"
 	cookieName       = "_auth"
+	stateCookieName  = "_auth_state"
+	stateTTL         = time.Minute * 5
 )
 
 var _ model.Authentication = Service[ProviderUser[string], string]{}
@@ -30,14 +36,22 @@ type Cache interface {
 	Load(ctx context.Context, key string) ([]byte, error)
 	Store(ctx context.Context, key string, value any, ttl time.Duration) error
 	Delete(ctx context.Context, keys ...string) error
+	Exclusive(ctx context.Context, name string, timeout time.Duration, action func(context.Context) error) (bool, error)
 }
 
 type Storage interface {
 	DoAtomic(ctx context.Context, action func(context.Context) error) error
 
-	GetInviteByToken(ctx context.Context, token string) (model.User, error)
-	Delete(ctx context.Context, user model.User) error
+	GetInviteByToken(ctx context.Context, token string) (model.Invitation, error)
+	UseInvite(ctx context.Context, invitation model.Invitation) error
 	DeleteInvite(ctx context.Context, user model.User) error
+
+	Create(ctx context.Context, name string) (model.User, error)
+	Delete(ctx context.Context, user model.User) error
+	GrantProfiles(ctx context.Context, user model.User, profiles []string) error
+
+	ListIdentities(ctx context.Context, user model.User) ([]model.Identity, error)
+	CountIdentities(ctx context.Context, user model.User) (int, error)
 }
 
 type ProviderUser[I comparable] interface {
@@ -45,29 +59,48 @@ type ProviderUser[I comparable] interface {
 }
 
 type (
-	LinkHandler                                    func(ctx context.Context, old, new model.User) error
-	CreateHandler[T ProviderUser[I], I comparable] func(ctx context.Context, invite model.User, user T) (model.User, error)
-	GetHandler[I comparable]                       func(ctx context.Context, id I) (model.User, error)
+	LinkHandler                                      func(ctx context.Context, old, new model.User) error
+	CreateHandler[T ProviderUser[I], I comparable]   func(ctx context.Context, invite model.User, user T) (model.User, error)
+	GetHandler[I comparable]                         func(ctx context.Context, id I) (model.User, error)
+	UpdateHandler[T ProviderUser[I], I comparable]   func(ctx context.Context, user model.User, providerUser T) (model.User, error)
+	DeleteHandler                                    func(ctx context.Context, user model.User) error
+	AccessHandler                                    func(ctx context.Context, client *http.Client) error
+	SyncHandler                                      func(ctx context.Context, client *http.Client, user model.User) error
+	ValidateHandler[T ProviderUser[I], I comparable] func(ctx context.Context, providerUser T) error
+)
+
+var (
+	ErrLastIdentity  = errors.New("unable to remove the last identity")
+	ErrLinkedToOther = errors.New("account already linked to another user")
+	ErrAlreadyLinked = errors.New("an account of this provider is already linked")
 )
 
 type Service[T ProviderUser[I], I comparable] struct {
-	config        oauth2.Config
-	cache         Cache
-	storage       Storage
-	renderer      *renderer.Service
-	linkHandler   LinkHandler
-	createHandler CreateHandler[T, I]
-	getHandler    GetHandler[I]
-	name          string
-	getURL        string
-	onSuccessPath string
-	cookie        cookie.Service[model.OAuthClaim]
+	config          oauth2.Config
+	cache           Cache
+	storage         Storage
+	renderer        *renderer.Service
+	linkHandler     LinkHandler
+	createHandler   CreateHandler[T, I]
+	getHandler      GetHandler[I]
+	updateHandler   UpdateHandler[T, I]
+	deleteHandler   DeleteHandler
+	revokeHandler   RevokeHandler
+	accessHandler   AccessHandler
+	syncHandler     SyncHandler
+	validateHandler ValidateHandler[T, I]
+	name            string
+	getURL          string
+	onSuccessPath   string
+	cookie          cookie.Service[model.OAuthClaim]
+	stateCookie     cookie.Service[StateClaim]
+	redirection     redirect.Service
 }
 
 var _ model.Authentication = Service[ProviderUser[string], string]{}
 
-func New[T ProviderUser[I], I comparable](name, getURL, onSuccessPath string, config oauth2.Config, cache Cache, storage Storage, linkHandler LinkHandler, createHandler CreateHandler[T, I], getHandler GetHandler[I], renderer *renderer.Service, cookie cookie.Service[model.OAuthClaim]) Service[T, I] {
-	return Service[T, I]{
+func New[T ProviderUser[I], I comparable](name, getURL, onSuccessPath string, config oauth2.Config, cache Cache, storage Storage, linkHandler LinkHandler, createHandler CreateHandler[T, I], getHandler GetHandler[I], updateHandler UpdateHandler[T, I], deleteHandler DeleteHandler, revokeHandler RevokeHandler, renderer *renderer.Service, cookieService cookie.Service[model.OAuthClaim], redirection redirect.Service, options ...Option[T, I]) Service[T, I] {
+	service := Service[T, I]{
 		name:          name,
 		getURL:        getURL,
 		onSuccessPath: onSuccessPath,
@@ -78,8 +111,45 @@ func New[T ProviderUser[I], I comparable](name, getURL, onSuccessPath string, co
 		linkHandler:   linkHandler,
 		createHandler: createHandler,
 		getHandler:    getHandler,
+		updateHandler: updateHandler,
+		deleteHandler: deleteHandler,
+		revokeHandler: revokeHandler,
 		renderer:      renderer,
-		cookie:        cookie,
+		cookie:        cookieService,
+		stateCookie:   cookie.Derive[StateClaim](cookieService, "state", stateTTL, http.SameSiteLaxMode),
+		redirection:   redirection,
+	}
+
+	for _, option := range options {
+		service = option(service)
+	}
+
+	return service
+}
+
+type Option[T ProviderUser[I], I comparable] func(Service[T, I]) Service[T, I]
+
+func WithAccessHandler[T ProviderUser[I], I comparable](accessHandler AccessHandler) Option[T, I] {
+	return func(instance Service[T, I]) Service[T, I] {
+		instance.accessHandler = accessHandler
+
+		return instance
+	}
+}
+
+func WithSyncHandler[T ProviderUser[I], I comparable](syncHandler SyncHandler) Option[T, I] {
+	return func(instance Service[T, I]) Service[T, I] {
+		instance.syncHandler = syncHandler
+
+		return instance
+	}
+}
+
+func WithValidateHandler[T ProviderUser[I], I comparable](validateHandler ValidateHandler[T, I]) Option[T, I] {
+	return func(instance Service[T, I]) Service[T, I] {
+		instance.validateHandler = validateHandler
+
+		return instance
 	}
 }
 
@@ -91,13 +161,33 @@ func (s Service[T, I]) RegisterPath(prefix string) string {
 	return prefix + "/register"
 }
 
+func (s Service[T, I]) LinkPath(prefix string) string {
+	return prefix + "/link"
+}
+
+func (s Service[T, I]) UnlinkPath(prefix string) string {
+	return prefix + "/unlink"
+}
+
 func (s Service[T, I]) Mux(prefix string, mux *http.ServeMux) {
-	mux.HandleFunc(prefix+"/logout", s.Logout)
+	mux.HandleFunc("POST "+prefix+"/logout", s.Logout)
 	mux.HandleFunc(s.RegisterPath(prefix), s.Register)
+	mux.HandleFunc(s.LinkPath(prefix), s.Link)
+	mux.HandleFunc(s.UnlinkPath(prefix), s.Unlink)
 	mux.HandleFunc(prefix+"/callback", s.Callback)
 }
 
 func (s Service[T, I]) Logout(w http.ResponseWriter, r *http.Request) {
+	if s.revokeHandler != nil {
+		if claim, err := s.cookie.Get(r, cookieName); err == nil && claim.Content.Token != nil && (len(claim.Content.Provider) == 0 || claim.Content.Provider == s.name) {
+			ctx := r.Context()
+
+			if err := s.revokeHandler(ctx, claim.Content.Token); err != nil {
+				slog.LogAttrs(ctx, slog.LevelWarn, "unable to revoke token", slog.String("provider", s.name), slog.Any("error", err))
+			}
+		}
+	}
+
 	s.cookie.Clear(w, cookieName)
 
 	s.renderer.Serve(w, r, renderer.NewPage("auth", http.StatusOK, map[string]any{
@@ -107,29 +197,94 @@ func (s Service[T, I]) Logout(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s Service[T, I]) Register(w http.ResponseWriter, r *http.Request) {
-	s.redirect(w, r, r.URL.Query().Get("registration"), r.URL.Query().Get("redirect"))
+	s.redirect(w, r, State{
+		Registration: r.URL.Query().Get("registration"),
+		Redirection:  r.URL.Query().Get("redirect"),
+	})
+}
+
+func (s Service[T, I]) Link(w http.ResponseWriter, r *http.Request) {
+	claim, err := s.cookie.Get(r, cookieName)
+	if err != nil || len(claim.Content.User.ID) == 0 {
+		s.renderer.Error(w, r, nil, httpModel.WrapUnauthorized(errors.New("login is required to link an account")))
+		return
+	}
+
+	s.redirect(w, r, State{
+		Link:        claim.Content.User.ID,
+		Redirection: r.URL.Query().Get("redirect"),
+	})
+}
+
+func (s Service[T, I]) Unlink(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := r.Context()
+	redirect := s.redirection.Sanitize(r.FormValue("redirect"), s.onSuccessPath)
+
+	claim, err := s.cookie.Get(r, cookieName)
+	if err != nil || len(claim.Content.User.ID) == 0 {
+		s.renderer.Error(w, r, nil, httpModel.WrapUnauthorized(errors.New("login is required to unlink an account")))
+		return
+	}
+
+	user := claim.Content.User
+
+	if err := s.unlink(ctx, user); err != nil {
+		if errors.Is(err, ErrLastIdentity) {
+			s.renderer.Redirect(w, r, redirect, renderer.NewErrorMessage("At least one account must remain linked"))
+			return
+		}
+
+		s.renderer.Error(w, r, nil, fmt.Errorf("unlink: %w", err))
+		return
+	}
+
+	if claim.Content.Provider == s.name {
+		if s.revokeHandler != nil && claim.Content.Token != nil {
+			if err := s.revokeHandler(ctx, claim.Content.Token); err != nil {
+				slog.LogAttrs(ctx, slog.LevelWarn, "unable to revoke token", slog.String("provider", s.name), slog.Any("error", err))
+			}
+		}
+
+		s.cookie.Clear(w, cookieName)
+	} else {
+		claim.Content.User.Identities = s.listIdentities(ctx, user)
+
+		if !s.cookie.Set(ctx, w, cookieName, claim.Content) {
+			return
+		}
+	}
+
+	s.renderer.Redirect(w, r, redirect, renderer.NewSuccessMessage("Account unlinked"))
 }
 
-func (s Service[T, I]) redirect(w http.ResponseWriter, r *http.Request, registration, redirect string) {
+func (s Service[T, I]) redirect(w http.ResponseWriter, r *http.Request, payload State) {
 	ctx := r.Context()
 	state := id.New()
+	payload.Redirection = s.redirection.Sanitize(payload.Redirection, s.onSuccessPath)
 
-	if len(registration) != 0 {
-		if _, err := s.storage.GetInviteByToken(ctx, registration); err != nil && errors.Is(err, model.ErrUnknownUser) {
+	if len(payload.Registration) != 0 {
+		if invitation, err := s.storage.GetInviteByToken(ctx, payload.Registration); err != nil && errors.Is(err, model.ErrUnknownUser) {
 			s.renderer.Serve(w, r, renderer.NewPage("auth", http.StatusOK, map[string]any{
-				"Redirect": redirect,
+				"Redirect": payload.Redirection,
 				"Message":  renderer.NewErrorMessage("Unknown registration code or already used"),
 			}))
 			return
+		} else if err == nil && !invitation.AllowProvider(s.name) {
+			s.renderer.Serve(w, r, renderer.NewPage("auth", http.StatusOK, map[string]any{
+				"Redirect": payload.Redirection,
+				"Message":  renderer.NewErrorMessage("Registration code not valid for this provider"),
+			}))
+			return
 		}
 	}
 
 	verifier := oauth2.GenerateVerifier()
-	payload := State{
-		Verifier:     verifier,
-		Registration: registration,
-		Redirection:  redirect,
-	}
+	payload.Verifier = verifier
 
 	rawPayload, err := json.Marshal(payload)
 	if err != nil {
@@ -137,28 +292,35 @@ func (s Service[T, I]) redirect(w http.ResponseWriter, r *http.Request, registra
 		return
 	}
 
-	if err := s.cache.Store(ctx, verifierCacheKey+state, rawPayload, time.Minute*5); err != nil {
+	if err := s.cache.Store(ctx, verifierCacheKey+state, rawPayload, stateTTL); err != nil {
 		s.renderer.Error(w, r, nil, fmt.Errorf("save state: %w", err))
 		return
 	}
 
+	if !s.stateCookie.Set(ctx, w, stateCookieName, StateClaim{State: state}) {
+		return
+	}
+
 	http.Redirect(w, r, s.config.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(verifier)), http.StatusFound)
 }
 
 func (s Service[T, I]) Callback(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
+	ctx := withShared(r.Context())
+	r = r.WithContext(ctx)
 
-	state := verifierCacheKey + r.URL.Query().Get("state")
+	state := r.URL.Query().Get("state")
 
-	rawPayload, err := s.cache.Load(ctx, state)
-	if err != nil {
-		s.renderer.Error(w, r, nil, httpModel.WrapNotFound(fmt.Errorf("state not found: %w", err)))
+	claim, err := s.stateCookie.Get(r, stateCookieName)
+	s.stateCookie.Clear(w, stateCookieName)
+
+	if err != nil || len(state) == 0 || claim.Content.State != state {
+		s.renderer.Error(w, r, nil, httpModel.WrapForbidden(errors.New("state not issued for this browser")))
 		return
 	}
 
-	var payload State
-	if err := json.Unmarshal(rawPayload, &payload); err != nil {
-		s.renderer.Error(w, r, nil, fmt.Errorf("unmarshal state: %w", err))
+	payload, err := s.consumeState(ctx, state)
+	if err != nil {
+		s.renderer.Error(w, r, nil, err)
 		return
 	}
 
@@ -169,6 +331,20 @@ func (s Service[T, I]) Callback(w http.ResponseWriter, r *http.Request) {
 	}
 
 	client := s.config.Client(ctx, oauth2Token)
+	redirect := s.redirection.Sanitize(payload.Redirection, s.onSuccessPath)
+
+	if s.accessHandler != nil {
+		if err := s.accessHandler(ctx, client); err != nil {
+			if errors.Is(err, model.ErrForbidden) {
+				s.renderForbidden(w, r, redirect, err)
+				return
+			}
+
+			s.renderer.Error(w, r, nil, fmt.Errorf("check access: %w", err))
+			return
+		}
+	}
+
 	resp, err := client.Get(s.getURL)
 	if err != nil {
 		s.renderer.Error(w, r, nil, fmt.Errorf("get user from provider: %w", err))
@@ -181,16 +357,32 @@ func (s Service[T, I]) Callback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	redirect := payload.Redirection
-	if len(redirect) == 0 {
-		redirect = s.onSuccessPath
+	if s.validateHandler != nil {
+		if err := s.validateHandler(ctx, providerUser); err != nil {
+			if errors.Is(err, model.ErrForbidden) {
+				s.renderForbidden(w, r, redirect, err)
+				return
+			}
+
+			s.renderer.Error(w, r, nil, fmt.Errorf("validate user: %w", err))
+			return
+		}
+	}
+
+	if len(payload.Link) != 0 {
+		s.callbackLink(w, r, payload.Link, oauth2Token, providerUser, redirect)
+		return
 	}
 
 	isRegistration := len(payload.Registration) != 0
 
 	user, err := s.getHandler(ctx, providerUser.GetID())
+	if err == nil {
+		user = s.syncUser(ctx, user, providerUser)
+	}
+
 	if err == nil && !isRegistration {
-		s.callbackSuccess(ctx, w, r, state, oauth2Token, user, redirect)
+		s.callbackSuccess(ctx, w, r, oauth2Token, user, redirect)
 		return
 	}
 
@@ -199,7 +391,7 @@ func (s Service[T, I]) Callback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	invite, err := s.storage.GetInviteByToken(ctx, payload.Registration)
+	invitation, err := s.storage.GetInviteByToken(ctx, payload.Registration)
 	if err != nil {
 		if errors.Is(err, model.ErrUnknownUser) {
 			s.renderer.Serve(w, r, renderer.NewPage("auth", http.StatusOK, map[string]any{
@@ -213,37 +405,176 @@ func (s Service[T, I]) Callback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !invitation.AllowProvider(s.name) {
+		s.renderer.Serve(w, r, renderer.NewPage("auth", http.StatusOK, map[string]any{
+			"Redirect": redirect,
+			"Message":  renderer.NewErrorMessage("Registration code not valid for this provider"),
+		}))
+		return
+	}
+
 	if err := s.storage.DoAtomic(ctx, func(ctx context.Context) (err error) {
-		if len(user.ID) == 0 {
-			user, err = s.createHandler(ctx, invite, providerUser)
-			if err != nil {
-				return err
-			}
+		user, err = Register(ctx, s.storage, s.linkHandler, invitation, user, func(ctx context.Context, invite model.User) (model.User, error) {
+			return s.createHandler(ctx, invite, providerUser)
+		})
+
+		return err
+	}); err != nil {
+		s.renderer.Error(w, r, nil, fmt.Errorf("upsert user: %w", err))
+		return
+	}
+
+	s.callbackSuccess(ctx, w, r, oauth2Token, user, redirect)
+}
+
+func (s Service[T, I]) unlink(ctx context.Context, user model.User) error {
+	return s.storage.DoAtomic(ctx, func(ctx context.Context) error {
+		count, err := s.storage.CountIdentities(ctx, user)
+		if err != nil {
+			return fmt.Errorf("count identities: %w", err)
 		}
 
-		if err := s.linkHandler(ctx, invite, user); err != nil {
-			return fmt.Errorf("invite handler: %w", err)
+		if count < 2 {
+			return ErrLastIdentity
 		}
 
-		if user.ID != invite.ID {
-			return s.storage.Delete(ctx, invite)
+		return s.deleteHandler(ctx, user)
+	})
+}
+
+func (s Service[T, I]) callbackLink(w http.ResponseWriter, r *http.Request, userID string, oauth2Token *oauth2.Token, providerUser T, redirect string) {
+	ctx := r.Context()
+
+	user, err := s.link(ctx, userID, providerUser)
+	if err != nil {
+		var message renderer.Message
+
+		switch {
+		case errors.Is(err, ErrLinkedToOther):
+			message = renderer.NewErrorMessage("This account is already linked to another user")
+		case errors.Is(err, ErrAlreadyLinked):
+			message = renderer.NewErrorMessage("Another account of this provider is already linked, unlink it first")
+		default:
+			s.renderer.Error(w, r, nil, fmt.Errorf("link user: %w", err))
+			return
 		}
 
-		return s.storage.DeleteInvite(ctx, invite)
-	}); err != nil {
-		s.renderer.Error(w, r, nil, fmt.Errorf("upsert user: %w", err))
+		s.renderer.Serve(w, r, renderer.NewPage("auth", http.StatusOK, map[string]any{
+			"Redirect": redirect,
+			"Message":  message,
+		}))
 		return
 	}
 
-	s.callbackSuccess(ctx, w, r, state, oauth2Token, user, redirect)
+	s.callbackSuccess(ctx, w, r, oauth2Token, user, redirect)
+}
+
+func (s Service[T, I]) link(ctx context.Context, userID string, providerUser T) (model.User, error) {
+	user, err := s.getHandler(ctx, providerUser.GetID())
+	switch {
+	case err == nil && user.ID != userID:
+		return model.User{}, ErrLinkedToOther
+
+	case err == nil:
+		return s.syncUser(ctx, user, providerUser), nil
+
+	case !errors.Is(err, model.ErrUnknownUser):
+		return model.User{}, fmt.Errorf("get user: %w", err)
+	}
+
+	identities, err := s.storage.ListIdentities(ctx, model.User{ID: userID})
+	if err != nil {
+		return model.User{}, fmt.Errorf("list identities: %w", err)
+	}
+
+	if kind, err := model.ParseUserKind(s.name); err == nil {
+		for _, identity := range identities {
+			if identity.Kind == kind {
+				return model.User{}, ErrAlreadyLinked
+			}
+		}
+	}
+
+	return s.createHandler(ctx, model.User{ID: userID}, providerUser)
+}
+
+func (s Service[T, I]) listIdentities(ctx context.Context, user model.User) []model.Identity {
+	identities, err := s.storage.ListIdentities(ctx, user)
+	if err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "unable to list identities", slog.String("id", user.ID), slog.Any("error", err))
+		return user.Identities
+	}
+
+	return identities
+}
+
+func (s Service[T, I]) syncUser(ctx context.Context, user model.User, providerUser T) model.User {
+	if s.updateHandler == nil {
+		return user
+	}
+
+	updated, err := s.updateHandler(ctx, user, providerUser)
+	if err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "unable to update user", slog.String("provider", s.name), slog.String("id", user.ID), slog.Any("error", err))
+		return user
+	}
+
+	return updated
+}
+
+func (s Service[T, I]) consumeState(ctx context.Context, state string) (State, error) {
+	var payload State
+
+	key := verifierCacheKey + state
+
+	acquired, err := s.cache.Exclusive(ctx, key+":consume", time.Second*30, func(ctx context.Context) error {
+		rawPayload, err := s.cache.Load(ctx, key)
+		if err != nil {
+			return httpModel.WrapNotFound(fmt.Errorf("state not found: %w", err))
+		}
+
+		if len(rawPayload) == 0 {
+			return httpModel.WrapNotFound(errors.New("state not found"))
+		}
+
+		if err := s.cache.Delete(ctx, key); err != nil {
+			return fmt.Errorf("delete state: %w", err)
+		}
+
+		if err := json.Unmarshal(rawPayload, &payload); err != nil {
+			return fmt.Errorf("unmarshal state: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return payload, err
+	}
+
+	if !acquired {
+		return payload, httpModel.WrapForbidden(errors.New("state already in use"))
+	}
+
+	return payload, nil
+}
+
+func (s Service[T, I]) renderForbidden(w http.ResponseWriter, r *http.Request, redirect string, err error) {
+	s.renderer.Serve(w, r, renderer.NewPage("auth", http.StatusForbidden, map[string]any{
+		"Redirect": redirect,
+		"Message":  renderer.NewErrorMessage("Access denied, %s", strings.TrimSuffix(err.Error(), ": "+model.ErrForbidden.Error())),
+	}))
 }
 
-func (s Service[T, I]) callbackSuccess(ctx context.Context, w http.ResponseWriter, r *http.Request, state string, oauth2Token *oauth2.Token, user model.User, redirect string) {
-	if err := s.cache.Delete(ctx, state); err != nil {
-		slog.ErrorContext(ctx, "unable to delete state", slog.Any("error", err))
+func (s Service[T, I]) callbackSuccess(ctx context.Context, w http.ResponseWriter, r *http.Request, oauth2Token *oauth2.Token, user model.User, redirect string) {
+	user.Identities = s.listIdentities(ctx, user)
+
+	if s.syncHandler != nil {
+		if err := s.syncHandler(ctx, oauth2.NewClient(ctx, oauth2.StaticTokenSource(oauth2Token)), user); err != nil {
+			slog.LogAttrs(ctx, slog.LevelError, "unable to sync user", slog.String("provider", s.name), slog.String("id", user.ID), slog.Any("error", err))
+		}
 	}
 
-	if !s.cookie.Set(ctx, w, cookieName, model.OAuthClaim{Token: oauth2Token, User: user}) {
+	if !s.cookie.Set(ctx, w, cookieName, model.OAuthClaim{Token: oauth2Token, Provider: s.name, User: user}) {
 		return
 	}
 
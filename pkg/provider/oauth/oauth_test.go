@@ -0,0 +1,225 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ViBiOh/auth/v3/internal/fake"
+	"github.com/ViBiOh/auth/v3/pkg/cookie"
+	"github.com/ViBiOh/auth/v3/pkg/model"
+)
+
+func TestConsumeState(t *testing.T) {
+	t.Parallel()
+
+	cache := fake.NewCache()
+	instance := Service[ProviderUser[string], string]{cache: cache}
+
+	rawPayload, err := json.Marshal(State{Verifier: "verifier", Redirection: "/"})
+	if err != nil {
+		t.Fatalf("marshal: %s", err)
+	}
+
+	ctx := context.Background()
+	_ = cache.Store(ctx, verifierCacheKey+"state", rawPayload, stateTTL)
+
+	var wg sync.WaitGroup
+	var success atomic.Int32
+
+	for range 10 {
+		wg.Go(func() {
+			if payload, err := instance.consumeState(ctx, "state"); err == nil && payload.Verifier == "verifier" {
+				success.Add(1)
+			}
+		})
+	}
+
+	wg.Wait()
+
+	if got := success.Load(); got != 1 {
+		t.Errorf("consumeState() succeeded %d times, want 1", got)
+	}
+
+	if _, err := instance.consumeState(ctx, "state"); err == nil {
+		t.Error("consumeState() = nil, want error on replay")
+	}
+}
+
+func TestStateCookieAudience(t *testing.T) {
+	t.Parallel()
+
+	sessionCookie := newTestCookie(t)
+	stateCookie := cookie.Derive[StateClaim](sessionCookie, "state", stateTTL, http.SameSiteLaxMode)
+
+	writer := httptest.NewRecorder()
+	stateCookie.Set(context.Background(), writer, cookieName, StateClaim{State: "state"})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, item := range writer.Result().Cookies() {
+		req.AddCookie(item)
+	}
+
+	if _, err := stateCookie.Get(req, cookieName); err != nil {
+		t.Errorf("Get() = `%s`, want state claim", err)
+	}
+
+	if _, err := sessionCookie.Get(req, cookieName); err == nil {
+		t.Error("Get() = nil, want state token rejected as session")
+	}
+
+	sessionRequest := newTestRequest(t, sessionCookie, http.MethodGet, "github", nil)
+	if _, err := stateCookie.Get(sessionRequest, cookieName); err == nil {
+		t.Error("Get() = nil, want session token rejected as state")
+	}
+}
+
+func TestLogoutMethod(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	Service[ProviderUser[string], string]{}.Mux("/oauth/github", mux)
+
+	writer := httptest.NewRecorder()
+	mux.ServeHTTP(writer, httptest.NewRequest(http.MethodGet, "/oauth/github/logout", nil))
+
+	if writer.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET logout = %d, want %d", writer.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+type testProviderUser struct {
+	ID string
+}
+
+func (tpu testProviderUser) GetID() string {
+	return tpu.ID
+}
+
+type testStorage struct {
+	Storage
+
+	identities map[string][]model.Identity
+}
+
+func (ts testStorage) DoAtomic(ctx context.Context, action func(context.Context) error) error {
+	return action(ctx)
+}
+
+func (ts testStorage) ListIdentities(_ context.Context, user model.User) ([]model.Identity, error) {
+	return ts.identities[user.ID], nil
+}
+
+func (ts testStorage) CountIdentities(_ context.Context, user model.User) (int, error) {
+	return len(ts.identities[user.ID]), nil
+}
+
+func newLinkService() Service[testProviderUser, string] {
+	return Service[testProviderUser, string]{
+		name: "github",
+		storage: testStorage{identities: map[string][]model.Identity{
+			"1": {{ID: "1", Kind: model.Email}},
+			"2": {{ID: "2", Kind: model.Email}, {ID: "2", Kind: model.GitHub}},
+		}},
+		getHandler: func(_ context.Context, id string) (model.User, error) {
+			if id == "octocat" {
+				return model.User{ID: "2"}, nil
+			}
+
+			return model.User{}, model.ErrUnknownUser
+		},
+		createHandler: func(_ context.Context, invite model.User, providerUser testProviderUser) (model.User, error) {
+			invite.Name = providerUser.ID
+
+			return invite, nil
+		},
+		deleteHandler: func(context.Context, model.User) error {
+			return nil
+		},
+	}
+}
+
+func TestLink(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		userID       string
+		providerUser testProviderUser
+		want         model.User
+		wantErr      error
+	}{
+		"new identity": {
+			"1",
+			testProviderUser{ID: "vibioh"},
+			model.User{ID: "1", Name: "vibioh"},
+			nil,
+		},
+		"already linked to the user": {
+			"2",
+			testProviderUser{ID: "octocat"},
+			model.User{ID: "2"},
+			nil,
+		},
+		"linked to another user": {
+			"1",
+			testProviderUser{ID: "octocat"},
+			model.User{},
+			ErrLinkedToOther,
+		},
+		"provider already linked": {
+			"2",
+			testProviderUser{ID: "vibioh"},
+			model.User{},
+			ErrAlreadyLinked,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := newLinkService().link(context.Background(), testCase.userID, testCase.providerUser)
+
+			if !errors.Is(gotErr, testCase.wantErr) {
+				t.Errorf("link() = `%s`, want `%s`", gotErr, testCase.wantErr)
+			}
+
+			if got.ID != testCase.want.ID || got.Name != testCase.want.Name {
+				t.Errorf("link() = %+v, want %+v", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestUnlink(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		user    model.User
+		wantErr error
+	}{
+		"last identity": {
+			model.User{ID: "1"},
+			ErrLastIdentity,
+		},
+		"other identity remains": {
+			model.User{ID: "2"},
+			nil,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			if gotErr := newLinkService().unlink(context.Background(), testCase.user); !errors.Is(gotErr, testCase.wantErr) {
+				t.Errorf("unlink() = `%s`, want `%s`", gotErr, testCase.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ViBiOh/auth/v3/pkg/model"
+)
+
+func Register(ctx context.Context, storage Storage, linkHandler LinkHandler, invitation model.Invitation, user model.User, create func(context.Context, model.User) (model.User, error)) (model.User, error) {
+	var err error
+
+	if invitation.IsMultiUse() {
+		user, err = registerShared(ctx, storage, invitation, user, create)
+	} else {
+		user, err = registerSingle(ctx, storage, linkHandler, invitation, user, create)
+	}
+
+	if err != nil {
+		return user, err
+	}
+
+	if err := storage.GrantProfiles(ctx, user, invitation.Profiles); err != nil {
+		return user, fmt.Errorf("grant profiles: %w", err)
+	}
+
+	return user, nil
+}
+
+func registerSingle(ctx context.Context, storage Storage, linkHandler LinkHandler, invitation model.Invitation, user model.User, create func(context.Context, model.User) (model.User, error)) (model.User, error) {
+	invite := invitation.User()
+
+	if len(user.ID) == 0 {
+		var err error
+
+		user, err = create(ctx, invite)
+		if err != nil {
+			return user, err
+		}
+	}
+
+	if err := linkHandler(ctx, invite, user); err != nil {
+		return user, fmt.Errorf("invite handler: %w", err)
+	}
+
+	if user.ID != invite.ID {
+		return user, storage.Delete(ctx, invite)
+	}
+
+	return user, storage.DeleteInvite(ctx, invite)
+}
+
+func registerShared(ctx context.Context, storage Storage, invitation model.Invitation, user model.User, create func(context.Context, model.User) (model.User, error)) (model.User, error) {
+	if err := storage.UseInvite(ctx, invitation); err != nil {
+		return user, fmt.Errorf("use invite: %w", err)
+	}
+
+	if len(user.ID) != 0 {
+		return user, nil
+	}
+
+	account, err := storage.Create(ctx, invitation.Description)
+	if err != nil {
+		return user, fmt.Errorf("create user: %w", err)
+	}
+
+	return create(ctx, account)
+}
@@ -0,0 +1,60 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+type RevokeHandler func(ctx context.Context, token *oauth2.Token) error
+
+func TokenRevoker(revokeURL, clientID, clientSecret string) RevokeHandler {
+	return func(ctx context.Context, token *oauth2.Token) error {
+		switch {
+		case len(token.RefreshToken) != 0:
+			return revokeToken(ctx, revokeURL, clientID, clientSecret, token.RefreshToken, "refresh_token")
+		case len(token.AccessToken) != 0:
+			return revokeToken(ctx, revokeURL, clientID, clientSecret, token.AccessToken, "access_token")
+		default:
+			return nil
+		}
+	}
+}
+
+func revokeToken(ctx context.Context, revokeURL, clientID, clientSecret, token, hint string) error {
+	form := url.Values{}
+	form.Set("token", token)
+	form.Set("token_type_hint", hint)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, revokeURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	if len(clientID) != 0 {
+		req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+	}
+
+	return doRevoke(req)
+}
+
+func doRevoke(req *http.Request) error {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("revoke: %w", err)
+	}
+
+	_ = resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("revoke: unexpected status %d", resp.StatusCode)
+	}
+
+	return nil
+}
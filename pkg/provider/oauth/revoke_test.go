@@ -0,0 +1,78 @@
+package oauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestTokenRevoker(t *testing.T) {
+	t.Parallel()
+
+	var mutex sync.Mutex
+	revoked := make(map[string][]string)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+
+		token := r.Form.Get("token")
+		if token == "unknown" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		clientID, _, _ := r.BasicAuth()
+
+		mutex.Lock()
+		revoked[clientID] = append(revoked[clientID], r.Form.Get("token_type_hint")+":"+token)
+		mutex.Unlock()
+	}))
+	t.Cleanup(server.Close)
+
+	cases := map[string]struct {
+		clientID string
+		token    *oauth2.Token
+		want     []string
+		wantErr  bool
+	}{
+		"access only": {
+			"access",
+			&oauth2.Token{AccessToken: "access"},
+			[]string{"access_token:access"},
+			false,
+		},
+		"with refresh": {
+			"refresh",
+			&oauth2.Token{AccessToken: "access", RefreshToken: "refresh"},
+			[]string{"refresh_token:refresh"},
+			false,
+		},
+		"error": {
+			"error",
+			&oauth2.Token{AccessToken: "unknown"},
+			nil,
+			true,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			gotErr := TokenRevoker(server.URL, testCase.clientID, "secret")(context.Background(), testCase.token)
+
+			mutex.Lock()
+			got := revoked[testCase.clientID]
+			mutex.Unlock()
+
+			if (gotErr != nil) != testCase.wantErr || !slices.Equal(got, testCase.want) {
+				t.Errorf("TokenRevoker() = (%v, `%s`), want (%v, error %t)", got, gotErr, testCase.want, testCase.wantErr)
+			}
+		})
+	}
+}
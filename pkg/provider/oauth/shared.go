@@ -0,0 +1,36 @@
+package oauth
+
+import (
+	"context"
+	"sync"
+)
+
+type sharedKey struct{}
+
+type sharedValue struct {
+	value any
+	err   error
+}
+
+func withShared(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sharedKey{}, new(sync.Map))
+}
+
+func Shared[V any](ctx context.Context, key string, fetch func() (V, error)) (V, error) {
+	store, ok := ctx.Value(sharedKey{}).(*sync.Map)
+	if !ok {
+		return fetch()
+	}
+
+	if content, ok := store.Load(key); ok {
+		shared := content.(sharedValue)
+		value, _ := shared.value.(V)
+
+		return value, shared.err
+	}
+
+	value, err := fetch()
+	store.Store(key, sharedValue{value: value, err: err})
+
+	return value, err
+}
@@ -0,0 +1,165 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const maxDepth = 16
+
+var errTruncated = errors.New("truncated cbor")
+
+func decodeCBOR(payload []byte) (any, []byte, error) {
+	return decodeItem(payload, 0)
+}
+
+func decodeItem(payload []byte, depth int) (any, []byte, error) {
+	if depth > maxDepth {
+		return nil, nil, errors.New("cbor too deep")
+	}
+
+	if len(payload) == 0 {
+		return nil, nil, errTruncated
+	}
+
+	major := payload[0] >> 5
+	info := payload[0] & 0x1f
+
+	if major == 7 {
+		switch info {
+		case 20:
+			return false, payload[1:], nil
+		case 21:
+			return true, payload[1:], nil
+		case 22, 23:
+			return nil, payload[1:], nil
+		default:
+			return nil, nil, fmt.Errorf("unhandled cbor simple value %d", info)
+		}
+	}
+
+	argument, rest, err := decodeArgument(info, payload[1:])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch major {
+	case 0:
+		if argument > 1<<62 {
+			return nil, nil, errors.New("cbor integer overflow")
+		}
+
+		return int64(argument), rest, nil
+
+	case 1:
+		if argument > 1<<62 {
+			return nil, nil, errors.New("cbor integer overflow")
+		}
+
+		return -1 - int64(argument), rest, nil
+
+	case 2, 3:
+		if uint64(len(rest)) < argument {
+			return nil, nil, errTruncated
+		}
+
+		if major == 2 {
+			return rest[:argument], rest[argument:], nil
+		}
+
+		return string(rest[:argument]), rest[argument:], nil
+
+	case 4:
+		if argument > uint64(len(rest)) {
+			return nil, nil, errTruncated
+		}
+
+		items := make([]any, 0, argument)
+
+		for range argument {
+			var item any
+
+			item, rest, err = decodeItem(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			items = append(items, item)
+		}
+
+		return items, rest, nil
+
+	case 5:
+		if argument > uint64(len(rest)) {
+			return nil, nil, errTruncated
+		}
+
+		items := make(map[any]any, argument)
+
+		for range argument {
+			var key, value any
+
+			key, rest, err = decodeItem(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, errors.New("unhandled cbor map key")
+			}
+
+			value, rest, err = decodeItem(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			items[key] = value
+		}
+
+		return items, rest, nil
+
+	default:
+		return nil, nil, fmt.Errorf("unhandled cbor major type %d", major)
+	}
+}
+
+func decodeArgument(info byte, payload []byte) (uint64, []byte, error) {
+	switch {
+	case info < 24:
+		return uint64(info), payload, nil
+
+	case info == 24:
+		if len(payload) < 1 {
+			return 0, nil, errTruncated
+		}
+
+		return uint64(payload[0]), payload[1:], nil
+
+	case info == 25:
+		if len(payload) < 2 {
+			return 0, nil, errTruncated
+		}
+
+		return uint64(binary.BigEndian.Uint16(payload)), payload[2:], nil
+
+	case info == 26:
+		if len(payload) < 4 {
+			return 0, nil, errTruncated
+		}
+
+		return uint64(binary.BigEndian.Uint32(payload)), payload[4:], nil
+
+	case info == 27:
+		if len(payload) < 8 {
+			return 0, nil, errTruncated
+		}
+
+		return binary.BigEndian.Uint64(payload), payload[8:], nil
+
+	default:
+		return 0, nil, errors.New("indefinite length cbor is not handled")
+	}
+}
@@ -0,0 +1,131 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+const (
+	coseKeyType   = 1
+	coseAlgorithm = 3
+	coseCurve     = -1
+	coseX         = -2
+	coseY         = -3
+	coseN         = -1
+	coseE         = -2
+
+	keyTypeOKP = 1
+	keyTypeEC2 = 2
+	keyTypeRSA = 3
+
+	algES256 = -7
+	algEdDSA = -8
+	algRS256 = -257
+
+	curveP256    = 1
+	curveEd25519 = 6
+)
+
+var errInvalidSignature = errors.New("invalid signature")
+
+type publicKey struct {
+	key       crypto.PublicKey
+	algorithm int64
+}
+
+func parsePublicKey(raw []byte) (publicKey, error) {
+	decoded, rest, err := decodeCBOR(raw)
+	if err != nil {
+		return publicKey{}, fmt.Errorf("decode: %w", err)
+	}
+
+	if len(rest) != 0 {
+		return publicKey{}, errors.New("trailing data after public key")
+	}
+
+	return coseKey(decoded)
+}
+
+func coseKey(decoded any) (publicKey, error) {
+	content, ok := decoded.(map[any]any)
+	if !ok {
+		return publicKey{}, errors.New("public key is not a map")
+	}
+
+	keyType, _ := content[int64(coseKeyType)].(int64)
+	algorithm, _ := content[int64(coseAlgorithm)].(int64)
+
+	switch {
+	case keyType == keyTypeEC2 && algorithm == algES256:
+		curve, _ := content[int64(coseCurve)].(int64)
+		x, _ := content[int64(coseX)].([]byte)
+		y, _ := content[int64(coseY)].([]byte)
+
+		if curve != curveP256 || len(x) != 32 || len(y) != 32 {
+			return publicKey{}, errors.New("invalid EC2 key")
+		}
+
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if _, err := key.ECDH(); err != nil {
+			return publicKey{}, fmt.Errorf("invalid EC2 point: %w", err)
+		}
+
+		return publicKey{key: key, algorithm: algorithm}, nil
+
+	case keyType == keyTypeOKP && algorithm == algEdDSA:
+		curve, _ := content[int64(coseCurve)].(int64)
+		x, _ := content[int64(coseX)].([]byte)
+
+		if curve != curveEd25519 || len(x) != ed25519.PublicKeySize {
+			return publicKey{}, errors.New("invalid OKP key")
+		}
+
+		return publicKey{key: ed25519.PublicKey(x), algorithm: algorithm}, nil
+
+	case keyType == keyTypeRSA && algorithm == algRS256:
+		n, _ := content[int64(coseN)].([]byte)
+		e, _ := content[int64(coseE)].([]byte)
+
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return publicKey{}, errors.New("invalid RSA key")
+		}
+
+		return publicKey{key: &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, algorithm: algorithm}, nil
+
+	default:
+		return publicKey{}, fmt.Errorf("unhandled key type %d with algorithm %d", keyType, algorithm)
+	}
+}
+
+func (pk publicKey) verify(data, signature []byte) error {
+	switch key := pk.key.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(data)
+		if !ecdsa.VerifyASN1(key, digest[:], signature) {
+			return errInvalidSignature
+		}
+
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, data, signature) {
+			return errInvalidSignature
+		}
+
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(data)
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) != nil {
+			return errInvalidSignature
+		}
+
+	default:
+		return errors.New("unhandled public key")
+	}
+
+	return nil
+}
@@ -0,0 +1,226 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttestedData = 0x40
+
+	typeCreate = "webauthn.create"
+	typeGet    = "webauthn.get"
+)
+
+type Bytes []byte
+
+func (b Bytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(b))
+}
+
+func (b *Bytes) UnmarshalJSON(payload []byte) error {
+	var content string
+	if err := json.Unmarshal(payload, &content); err != nil {
+		return err
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(content, "="))
+	if err != nil {
+		return fmt.Errorf("decode base64url: %w", err)
+	}
+
+	*b = decoded
+
+	return nil
+}
+
+type RelyingParty struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name"`
+}
+
+type UserEntity struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+	ID          Bytes  `json:"id"`
+}
+
+type CredentialParameter struct {
+	Type      string `json:"type"`
+	Algorithm int64  `json:"alg"`
+}
+
+type CredentialDescriptor struct {
+	Type string `json:"type"`
+	ID   Bytes  `json:"id"`
+}
+
+type AuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+type CreationOptions struct {
+	RP                     RelyingParty           `json:"rp"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+	User                   UserEntity             `json:"user"`
+	Challenge              Bytes                  `json:"challenge"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials,omitempty"`
+	Timeout                int64                  `json:"timeout"`
+}
+
+type RequestOptions struct {
+	RPID             string                 `json:"rpId"`
+	UserVerification string                 `json:"userVerification"`
+	Challenge        Bytes                  `json:"challenge"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials,omitempty"`
+	Timeout          int64                  `json:"timeout"`
+}
+
+type RegistrationResponse struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	RawID    Bytes  `json:"rawId"`
+	Response struct {
+		ClientDataJSON    Bytes `json:"clientDataJSON"`
+		AttestationObject Bytes `json:"attestationObject"`
+	} `json:"response"`
+}
+
+type AssertionResponse struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	RawID    Bytes  `json:"rawId"`
+	Response struct {
+		ClientDataJSON    Bytes `json:"clientDataJSON"`
+		AuthenticatorData Bytes `json:"authenticatorData"`
+		Signature         Bytes `json:"signature"`
+		UserHandle        Bytes `json:"userHandle,omitempty"`
+	} `json:"response"`
+}
+
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+func parseClientData(raw []byte, wantedType, origin string) ([]byte, error) {
+	var content clientData
+	if err := json.Unmarshal(raw, &content); err != nil {
+		return nil, fmt.Errorf("unmarshal client data: %w", err)
+	}
+
+	if content.Type != wantedType {
+		return nil, fmt.Errorf("unexpected ceremony type `%s`", content.Type)
+	}
+
+	if content.Origin != origin {
+		return nil, fmt.Errorf("unexpected origin `%s`", content.Origin)
+	}
+
+	challenge, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(content.Challenge, "="))
+	if err != nil || len(challenge) == 0 {
+		return nil, errors.New("invalid challenge")
+	}
+
+	return challenge, nil
+}
+
+type authenticatorData struct {
+	rpIDHash     []byte
+	credentialID []byte
+	publicKey    []byte
+	signCount    uint32
+	flags        byte
+}
+
+func parseAuthenticatorData(raw []byte) (authenticatorData, error) {
+	var output authenticatorData
+
+	if len(raw) < 37 {
+		return output, errors.New("authenticator data too short")
+	}
+
+	output.rpIDHash = raw[:32]
+	output.flags = raw[32]
+	output.signCount = binary.BigEndian.Uint32(raw[33:37])
+
+	if output.flags&flagAttestedData == 0 {
+		return output, nil
+	}
+
+	rest := raw[37:]
+	if len(rest) < 18 {
+		return output, errors.New("attested credential data too short")
+	}
+
+	idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+
+	if idLength == 0 || len(rest) < idLength {
+		return output, errors.New("invalid credential id")
+	}
+
+	output.credentialID = rest[:idLength]
+	rest = rest[idLength:]
+
+	_, remaining, err := decodeCBOR(rest)
+	if err != nil {
+		return output, fmt.Errorf("decode public key: %w", err)
+	}
+
+	output.publicKey = rest[:len(rest)-len(remaining)]
+
+	return output, nil
+}
+
+func (ad authenticatorData) check(rpID string, userVerification bool) error {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	if !bytes.Equal(ad.rpIDHash, rpIDHash[:]) {
+		return errors.New("relying party mismatch")
+	}
+
+	if ad.flags&flagUserPresent == 0 {
+		return errors.New("user not present")
+	}
+
+	if userVerification && ad.flags&flagUserVerified == 0 {
+		return errors.New("user not verified")
+	}
+
+	return nil
+}
+
+func parseAttestation(raw []byte) ([]byte, error) {
+	decoded, _, err := decodeCBOR(raw)
+	if err != nil {
+		return nil, fmt.Errorf("decode attestation: %w", err)
+	}
+
+	content, ok := decoded.(map[any]any)
+	if !ok {
+		return nil, errors.New("attestation is not a map")
+	}
+
+	if _, ok := content["fmt"].(string); !ok {
+		return nil, errors.New("attestation format is missing")
+	}
+
+	authData, ok := content["authData"].([]byte)
+	if !ok {
+		return nil, errors.New("authenticator data is missing")
+	}
+
+	return authData, nil
+}
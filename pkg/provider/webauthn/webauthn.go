@@ -0,0 +1,626 @@
+package webauthn
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ViBiOh/auth/v3/pkg/cookie"
+	"github.com/ViBiOh/auth/v3/pkg/model"
+	"github.com/ViBiOh/auth/v3/pkg/provider/oauth"
+	"github.com/ViBiOh/auth/v3/pkg/redirect"
+	"github.com/ViBiOh/flags"
+	"github.com/ViBiOh/httputils/v4/pkg/httperror"
+	"github.com/ViBiOh/httputils/v4/pkg/httpjson"
+	httpModel "github.com/ViBiOh/httputils/v4/pkg/model"
+	"github.com/ViBiOh/httputils/v4/pkg/renderer"
+)
+
+const (
+	name            = "webauthn"
+	cookieName      = "_auth"
+	challengePrefix = "auth:webauthn:"
+	challengeLength = 32
+)
+
+var (
+	ErrInvalidCredential   = errors.New("invalid webauthn credential")
+	ErrClonedAuthenticator = errors.New("authenticator sign counter did not increase")
+)
+
+var _ model.Authentication = Service{}
+
+type Storage interface {
+	List(ctx context.Context, ids ...string) ([]model.User, error)
+	ListIdentities(ctx context.Context, user model.User) ([]model.Identity, error)
+
+	ListWebAuthnCredentials(ctx context.Context, user model.User) ([]model.WebAuthnCredential, error)
+	GetWebAuthnCredential(ctx context.Context, id []byte) (model.WebAuthnCredential, error)
+	CreateWebAuthnCredential(ctx context.Context, credential model.WebAuthnCredential) error
+	UpdateWebAuthnSignCount(ctx context.Context, credential model.WebAuthnCredential) error
+	DeleteWebAuthnCredential(ctx context.Context, user model.User, id []byte) error
+}
+
+type Config struct {
+	rpID             string
+	rpName           string
+	origin           string
+	userVerification string
+	onSuccessPath    string
+	timeout          time.Duration
+}
+
+type Service struct {
+	cache            oauth.Cache
+	storage          Storage
+	renderer         *renderer.Service
+	cookie           cookie.Service[model.OAuthClaim]
+	redirection      redirect.Service
+	rpID             string
+	rpName           string
+	origin           string
+	userVerification string
+	onSuccessPath    string
+	timeout          time.Duration
+}
+
+type challengeState struct {
+	Type   string `json:"type"`
+	UserID string `json:"user_id,omitempty"`
+}
+
+func Flags(fs *flag.FlagSet, prefix string, overrides ...flags.Override) *Config {
+	var config Config
+
+	flags.New("RPID", "Relying Party ID, the domain credentials are scoped to").Prefix(prefix).DocPrefix("webauthn").StringVar(fs, &config.rpID, "localhost", overrides)
+	flags.New("RPName", "Relying Party display name").Prefix(prefix).DocPrefix("webauthn").StringVar(fs, &config.rpName, "auth", overrides)
+	flags.New("Origin", "Expected origin of the ceremonies").Prefix(prefix).DocPrefix("webauthn").StringVar(fs, &config.origin, "http://localhost:1080", overrides)
+	flags.New("UserVerification", "User verification requirement: required, preferred or discouraged").Prefix(prefix).DocPrefix("webauthn").StringVar(fs, &config.userVerification, "preferred", overrides)
+	flags.New("OnSuccessPath", "Path for redirecting on success").Prefix(prefix).DocPrefix("webauthn").StringVar(fs, &config.onSuccessPath, "/", overrides)
+	flags.New("Timeout", "Validity of a ceremony challenge").Prefix(prefix).DocPrefix("webauthn").DurationVar(fs, &config.timeout, time.Minute*5, overrides)
+
+	return &config
+}
+
+func New(config *Config, cache oauth.Cache, storage Storage, renderer *renderer.Service, cookie cookie.Service[model.OAuthClaim], redirection redirect.Service) Service {
+	return Service{
+		cache:            cache,
+		storage:          storage,
+		renderer:         renderer,
+		cookie:           cookie,
+		redirection:      redirection,
+		rpID:             config.rpID,
+		rpName:           config.rpName,
+		origin:           strings.TrimSuffix(config.origin, "/"),
+		userVerification: config.userVerification,
+		onSuccessPath:    config.onSuccessPath,
+		timeout:          config.timeout,
+	}
+}
+
+func (s Service) Name() string {
+	return name
+}
+
+func (s Service) RegisterPath(prefix string) string {
+	return prefix
+}
+
+func (s Service) BeginRegistration(ctx context.Context, user model.User) (CreationOptions, error) {
+	credentials, err := s.storage.ListWebAuthnCredentials(ctx, user)
+	if err != nil {
+		return CreationOptions{}, fmt.Errorf("list credentials: %w", err)
+	}
+
+	challenge, err := s.newChallenge(ctx, challengeState{Type: typeCreate, UserID: user.ID})
+	if err != nil {
+		return CreationOptions{}, err
+	}
+
+	return CreationOptions{
+		RP: RelyingParty{ID: s.rpID, Name: s.rpName},
+		User: UserEntity{
+			ID:          Bytes(user.ID),
+			Name:        user.Name,
+			DisplayName: user.Name,
+		},
+		Challenge: challenge,
+		PubKeyCredParams: []CredentialParameter{
+			{Type: "public-key", Algorithm: algES256},
+			{Type: "public-key", Algorithm: algEdDSA},
+			{Type: "public-key", Algorithm: algRS256},
+		},
+		ExcludeCredentials: descriptors(credentials),
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: s.userVerification,
+		},
+		Attestation: "none",
+		Timeout:     s.timeout.Milliseconds(),
+	}, nil
+}
+
+func (s Service) FinishRegistration(ctx context.Context, user model.User, response RegistrationResponse, label string) (model.WebAuthnCredential, error) {
+	challenge, err := parseClientData(response.Response.ClientDataJSON, typeCreate, s.origin)
+	if err != nil {
+		return model.WebAuthnCredential{}, fmt.Errorf("%w: %w", ErrInvalidCredential, err)
+	}
+
+	state, err := s.consumeChallenge(ctx, challenge, typeCreate)
+	if err != nil {
+		return model.WebAuthnCredential{}, err
+	}
+
+	if state.UserID != user.ID {
+		return model.WebAuthnCredential{}, fmt.Errorf("%w: challenge issued for another user", ErrInvalidCredential)
+	}
+
+	rawAuthData, err := parseAttestation(response.Response.AttestationObject)
+	if err != nil {
+		return model.WebAuthnCredential{}, fmt.Errorf("%w: %w", ErrInvalidCredential, err)
+	}
+
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return model.WebAuthnCredential{}, fmt.Errorf("%w: %w", ErrInvalidCredential, err)
+	}
+
+	if err := authData.check(s.rpID, s.userVerification == "required"); err != nil {
+		return model.WebAuthnCredential{}, fmt.Errorf("%w: %w", ErrInvalidCredential, err)
+	}
+
+	if len(authData.credentialID) == 0 {
+		return model.WebAuthnCredential{}, fmt.Errorf("%w: no attested credential", ErrInvalidCredential)
+	}
+
+	if _, err := parsePublicKey(authData.publicKey); err != nil {
+		return model.WebAuthnCredential{}, fmt.Errorf("%w: %w", ErrInvalidCredential, err)
+	}
+
+	if _, err := s.storage.GetWebAuthnCredential(ctx, authData.credentialID); err == nil {
+		return model.WebAuthnCredential{}, fmt.Errorf("%w: credential already registered", ErrInvalidCredential)
+	} else if !errors.Is(err, model.ErrUnknownUser) {
+		return model.WebAuthnCredential{}, fmt.Errorf("get credential: %w", err)
+	}
+
+	credential := model.WebAuthnCredential{
+		ID:        authData.credentialID,
+		UserID:    user.ID,
+		PublicKey: authData.publicKey,
+		Name:      label,
+		SignCount: authData.signCount,
+		Creation:  time.Now(),
+	}
+
+	if err := s.storage.CreateWebAuthnCredential(ctx, credential); err != nil {
+		return model.WebAuthnCredential{}, fmt.Errorf("create credential: %w", err)
+	}
+
+	return credential, nil
+}
+
+func (s Service) BeginLogin(ctx context.Context, user model.User) (RequestOptions, error) {
+	var allowed []CredentialDescriptor
+
+	if len(user.ID) != 0 {
+		credentials, err := s.storage.ListWebAuthnCredentials(ctx, user)
+		if err != nil {
+			return RequestOptions{}, fmt.Errorf("list credentials: %w", err)
+		}
+
+		allowed = descriptors(credentials)
+	}
+
+	challenge, err := s.newChallenge(ctx, challengeState{Type: typeGet, UserID: user.ID})
+	if err != nil {
+		return RequestOptions{}, err
+	}
+
+	return RequestOptions{
+		RPID:             s.rpID,
+		UserVerification: s.userVerification,
+		Challenge:        challenge,
+		AllowCredentials: allowed,
+		Timeout:          s.timeout.Milliseconds(),
+	}, nil
+}
+
+func (s Service) FinishLogin(ctx context.Context, response AssertionResponse) (model.WebAuthnCredential, error) {
+	return s.finishLogin(ctx, response, "")
+}
+
+func (s Service) finishLogin(ctx context.Context, response AssertionResponse, userID string) (model.WebAuthnCredential, error) {
+	challenge, err := parseClientData(response.Response.ClientDataJSON, typeGet, s.origin)
+	if err != nil {
+		return model.WebAuthnCredential{}, fmt.Errorf("%w: %w", ErrInvalidCredential, err)
+	}
+
+	state, err := s.consumeChallenge(ctx, challenge, typeGet)
+	if err != nil {
+		return model.WebAuthnCredential{}, err
+	}
+
+	credentialID := []byte(response.RawID)
+	if len(credentialID) == 0 {
+		if credentialID, err = base64.RawURLEncoding.DecodeString(response.ID); err != nil {
+			return model.WebAuthnCredential{}, fmt.Errorf("%w: invalid credential id", ErrInvalidCredential)
+		}
+	}
+
+	credential, err := s.storage.GetWebAuthnCredential(ctx, credentialID)
+	if err != nil {
+		if errors.Is(err, model.ErrUnknownUser) {
+			return model.WebAuthnCredential{}, fmt.Errorf("%w: unknown credential", ErrInvalidCredential)
+		}
+
+		return model.WebAuthnCredential{}, fmt.Errorf("get credential: %w", err)
+	}
+
+	for _, expected := range []string{state.UserID, userID, string(response.Response.UserHandle)} {
+		if len(expected) != 0 && expected != credential.UserID {
+			return model.WebAuthnCredential{}, fmt.Errorf("%w: credential belongs to another user", ErrInvalidCredential)
+		}
+	}
+
+	authData, err := parseAuthenticatorData(response.Response.AuthenticatorData)
+	if err != nil {
+		return model.WebAuthnCredential{}, fmt.Errorf("%w: %w", ErrInvalidCredential, err)
+	}
+
+	if err := authData.check(s.rpID, s.userVerification == "required"); err != nil {
+		return model.WebAuthnCredential{}, fmt.Errorf("%w: %w", ErrInvalidCredential, err)
+	}
+
+	key, err := parsePublicKey(credential.PublicKey)
+	if err != nil {
+		return model.WebAuthnCredential{}, fmt.Errorf("parse public key: %w", err)
+	}
+
+	clientDataHash := sha256.Sum256(response.Response.ClientDataJSON)
+	signed := append(bytes.Clone(response.Response.AuthenticatorData), clientDataHash[:]...)
+
+	if err := key.verify(signed, response.Response.Signature); err != nil {
+		return model.WebAuthnCredential{}, fmt.Errorf("%w: %w", ErrInvalidCredential, err)
+	}
+
+	if (authData.signCount != 0 || credential.SignCount != 0) && authData.signCount <= credential.SignCount {
+		return model.WebAuthnCredential{}, ErrClonedAuthenticator
+	}
+
+	credential.SignCount = authData.signCount
+	credential.LastUsed = time.Now()
+
+	if err := s.storage.UpdateWebAuthnSignCount(ctx, credential); err != nil {
+		return model.WebAuthnCredential{}, fmt.Errorf("update sign count: %w", err)
+	}
+
+	return credential, nil
+}
+
+func (s Service) Authenticate(ctx context.Context, response AssertionResponse) (model.User, error) {
+	credential, err := s.FinishLogin(ctx, response)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	users, err := s.storage.List(ctx, credential.UserID)
+	if err != nil {
+		return model.User{}, fmt.Errorf("get user: %w", err)
+	}
+
+	if len(users) == 0 {
+		return model.User{}, model.ErrUnknownUser
+	}
+
+	user := users[0]
+
+	if identities, err := s.storage.ListIdentities(ctx, user); err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "unable to list identities", slog.String("id", user.ID), slog.Any("error", err))
+	} else {
+		user.Identities = identities
+	}
+
+	return user, nil
+}
+
+func (s Service) newChallenge(ctx context.Context, state challengeState) ([]byte, error) {
+	challenge := make([]byte, challengeLength)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, fmt.Errorf("generate challenge: %w", err)
+	}
+
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
+	}
+
+	if err := s.cache.Store(ctx, challengeKey(challenge), payload, s.timeout); err != nil {
+		return nil, fmt.Errorf("store challenge: %w", err)
+	}
+
+	return challenge, nil
+}
+
+func (s Service) consumeChallenge(ctx context.Context, challenge []byte, wantedType string) (challengeState, error) {
+	var state challengeState
+
+	key := challengeKey(challenge)
+
+	acquired, err := s.cache.Exclusive(ctx, key+":consume", time.Second*30, func(ctx context.Context) error {
+		payload, err := s.cache.Load(ctx, key)
+		if err != nil {
+			return fmt.Errorf("load challenge: %w", err)
+		}
+
+		if len(payload) == 0 {
+			return fmt.Errorf("%w: challenge expired or already used", ErrInvalidCredential)
+		}
+
+		if err := s.cache.Delete(ctx, key); err != nil {
+			return fmt.Errorf("delete challenge: %w", err)
+		}
+
+		if err := json.Unmarshal(payload, &state); err != nil {
+			return fmt.Errorf("unmarshal challenge: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return state, err
+	}
+
+	if !acquired {
+		return state, fmt.Errorf("%w: challenge already in use", ErrInvalidCredential)
+	}
+
+	if state.Type != wantedType {
+		return state, fmt.Errorf("%w: challenge issued for another ceremony", ErrInvalidCredential)
+	}
+
+	return state, nil
+}
+
+func challengeKey(challenge []byte) string {
+	return challengePrefix + base64.RawURLEncoding.EncodeToString(challenge)
+}
+
+func descriptors(credentials []model.WebAuthnCredential) []CredentialDescriptor {
+	output := make([]CredentialDescriptor, 0, len(credentials))
+
+	for _, credential := range credentials {
+		output = append(output, CredentialDescriptor{Type: "public-key", ID: credential.ID})
+	}
+
+	return output
+}
+
+func (s Service) Required(ctx context.Context, user model.User) (bool, error) {
+	credentials, err := s.storage.ListWebAuthnCredentials(ctx, user)
+	if err != nil {
+		return false, fmt.Errorf("list credentials: %w", err)
+	}
+
+	return len(credentials) != 0, nil
+}
+
+func (s Service) Verify(ctx context.Context, user model.User, code string) error {
+	var response AssertionResponse
+	if err := json.Unmarshal([]byte(code), &response); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidCredential, err)
+	}
+
+	_, err := s.finishLogin(ctx, response, user.ID)
+
+	return err
+}
+
+func (s Service) GetUser(_ context.Context, _ http.ResponseWriter, r *http.Request) (model.User, error) {
+	claim, err := s.cookie.Get(r, cookieName)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	if len(claim.Content.User.ID) == 0 {
+		return model.User{}, errors.New("no content")
+	}
+
+	if claim.Content.Provider != name {
+		return model.User{}, oauth.ErrOtherProvider
+	}
+
+	return claim.Content.User, nil
+}
+
+func (s Service) OnUnauthorized(w http.ResponseWriter, r *http.Request, _ error) {
+	s.renderForm(w, r, r.URL.String())
+}
+
+func (s Service) Logout(w http.ResponseWriter, r *http.Request) {
+	s.cookie.Clear(w, cookieName)
+
+	s.renderer.Serve(w, r, renderer.NewPage("auth", http.StatusOK, map[string]any{
+		"Redirect": "/",
+		"Message":  renderer.NewSuccessMessage("Logout success!"),
+	}))
+}
+
+func (s Service) Mux(prefix string, mux *http.ServeMux) {
+	mux.HandleFunc("GET "+prefix, s.Form)
+	mux.HandleFunc("POST "+prefix+"/login/begin", s.handleBeginLogin)
+	mux.HandleFunc("POST "+prefix+"/login/finish", s.handleFinishLogin)
+	mux.HandleFunc("POST "+prefix+"/logout", s.Logout)
+}
+
+func (s Service) CredentialsMux(prefix string, mux *http.ServeMux) {
+	mux.HandleFunc("GET "+prefix, s.handleList)
+	mux.HandleFunc("POST "+prefix+"/begin", s.handleBeginRegistration)
+	mux.HandleFunc("POST "+prefix+"/finish", s.handleFinishRegistration)
+	mux.HandleFunc("DELETE "+prefix+"/{id}", s.handleDelete)
+}
+
+func (s Service) Form(w http.ResponseWriter, r *http.Request) {
+	s.renderForm(w, r, r.URL.Query().Get("redirect"))
+}
+
+func (s Service) renderForm(w http.ResponseWriter, r *http.Request, redirection string) {
+	s.renderer.Serve(w, r, renderer.NewPage("passkey", http.StatusOK, map[string]any{
+		"Redirect": s.redirection.Sanitize(redirection, s.onSuccessPath),
+	}))
+}
+
+func (s Service) handleBeginLogin(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	options, err := s.BeginLogin(ctx, model.User{})
+	if err != nil {
+		httperror.InternalServerError(ctx, w, err)
+		return
+	}
+
+	httpjson.Write(ctx, w, http.StatusOK, options)
+}
+
+func (s Service) BeginSecondFactor(pendingUser func(*http.Request) (model.User, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		user, err := pendingUser(r)
+		if err != nil {
+			httperror.Unauthorized(ctx, w, err)
+			return
+		}
+
+		options, err := s.BeginLogin(ctx, user)
+		if err != nil {
+			httperror.InternalServerError(ctx, w, err)
+			return
+		}
+
+		httpjson.Write(ctx, w, http.StatusOK, options)
+	}
+}
+
+func (s Service) handleFinishLogin(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	response, err := httpjson.Parse[AssertionResponse](r)
+	if err != nil {
+		httperror.BadRequest(ctx, w, err)
+		return
+	}
+
+	user, err := s.Authenticate(ctx, response)
+	if err != nil {
+		handleError(ctx, w, err)
+		return
+	}
+
+	if !s.cookie.Set(ctx, w, cookieName, model.OAuthClaim{Provider: name, User: user}) {
+		return
+	}
+
+	httpjson.Write(ctx, w, http.StatusOK, user)
+}
+
+func (s Service) handleList(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user := model.ReadUser(ctx)
+	if len(user.ID) == 0 {
+		httperror.Unauthorized(ctx, w, errors.New("login is required"))
+		return
+	}
+
+	credentials, err := s.storage.ListWebAuthnCredentials(ctx, user)
+	if err != nil {
+		httperror.InternalServerError(ctx, w, err)
+		return
+	}
+
+	httpjson.Write(ctx, w, http.StatusOK, credentials)
+}
+
+func (s Service) handleBeginRegistration(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user := model.ReadUser(ctx)
+	if len(user.ID) == 0 {
+		httperror.Unauthorized(ctx, w, errors.New("login is required"))
+		return
+	}
+
+	options, err := s.BeginRegistration(ctx, user)
+	if err != nil {
+		httperror.InternalServerError(ctx, w, err)
+		return
+	}
+
+	httpjson.Write(ctx, w, http.StatusOK, options)
+}
+
+func (s Service) handleFinishRegistration(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user := model.ReadUser(ctx)
+	if len(user.ID) == 0 {
+		httperror.Unauthorized(ctx, w, errors.New("login is required"))
+		return
+	}
+
+	response, err := httpjson.Parse[RegistrationResponse](r)
+	if err != nil {
+		httperror.BadRequest(ctx, w, err)
+		return
+	}
+
+	credential, err := s.FinishRegistration(ctx, user, response, r.URL.Query().Get("name"))
+	if err != nil {
+		handleError(ctx, w, err)
+		return
+	}
+
+	httpjson.Write(ctx, w, http.StatusCreated, credential)
+}
+
+func (s Service) handleDelete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user := model.ReadUser(ctx)
+	if len(user.ID) == 0 {
+		httperror.Unauthorized(ctx, w, errors.New("login is required"))
+		return
+	}
+
+	id, err := base64.RawURLEncoding.DecodeString(r.PathValue("id"))
+	if err != nil {
+		httperror.BadRequest(ctx, w, httpModel.WrapInvalid(err))
+		return
+	}
+
+	if err := s.storage.DeleteWebAuthnCredential(ctx, user, id); err != nil {
+		httperror.InternalServerError(ctx, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func handleError(ctx context.Context, w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrInvalidCredential) || errors.Is(err, ErrClonedAuthenticator) {
+		httperror.Unauthorized(ctx, w, err)
+		return
+	}
+
+	httperror.InternalServerError(ctx, w, err)
+}
@@ -0,0 +1,567 @@
+package webauthn
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ViBiOh/auth/v3/internal/fake"
+	"github.com/ViBiOh/auth/v3/pkg/cookie"
+	"github.com/ViBiOh/auth/v3/pkg/model"
+	"github.com/ViBiOh/auth/v3/pkg/provider/basic"
+	"github.com/ViBiOh/auth/v3/pkg/redirect"
+)
+
+type testStorage struct {
+	credentials map[string]model.WebAuthnCredential
+	mutex       sync.Mutex
+}
+
+func (ts *testStorage) List(_ context.Context, ids ...string) ([]model.User, error) {
+	users := make([]model.User, 0, len(ids))
+	for _, id := range ids {
+		users = append(users, model.User{ID: id, Name: id})
+	}
+
+	return users, nil
+}
+
+func (ts *testStorage) ListIdentities(context.Context, model.User) ([]model.Identity, error) {
+	return nil, nil
+}
+
+func (ts *testStorage) ListWebAuthnCredentials(_ context.Context, user model.User) ([]model.WebAuthnCredential, error) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	var output []model.WebAuthnCredential
+
+	for _, credential := range ts.credentials {
+		if credential.UserID == user.ID {
+			output = append(output, credential)
+		}
+	}
+
+	return output, nil
+}
+
+func (ts *testStorage) GetWebAuthnCredential(_ context.Context, id []byte) (model.WebAuthnCredential, error) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	credential, ok := ts.credentials[string(id)]
+	if !ok {
+		return credential, model.ErrUnknownUser
+	}
+
+	return credential, nil
+}
+
+func (ts *testStorage) CreateWebAuthnCredential(_ context.Context, credential model.WebAuthnCredential) error {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	ts.credentials[string(credential.ID)] = credential
+
+	return nil
+}
+
+func (ts *testStorage) UpdateWebAuthnSignCount(_ context.Context, credential model.WebAuthnCredential) error {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	ts.credentials[string(credential.ID)] = credential
+
+	return nil
+}
+
+func (ts *testStorage) DeleteWebAuthnCredential(_ context.Context, _ model.User, id []byte) error {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	delete(ts.credentials, string(id))
+
+	return nil
+}
+
+// softAuthenticator is a minimal ES256 authenticator producing "none" attestations
+type softAuthenticator struct {
+	key     *ecdsa.PrivateKey
+	id      []byte
+	rpID    string
+	origin  string
+	counter uint32
+}
+
+func newSoftAuthenticator(t *testing.T, rpID, origin string) *softAuthenticator {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %s", err)
+	}
+
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+
+	return &softAuthenticator{key: key, id: id, rpID: rpID, origin: origin}
+}
+
+func (sa *softAuthenticator) clientData(ceremony string, challenge []byte) []byte {
+	payload, _ := json.Marshal(clientData{
+		Type:      ceremony,
+		Challenge: base64.RawURLEncoding.EncodeToString(challenge),
+		Origin:    sa.origin,
+	})
+
+	return payload
+}
+
+func (sa *softAuthenticator) authData(flags byte, attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(sa.rpID))
+
+	output := append(rpIDHash[:], flags)
+	output = binary.BigEndian.AppendUint32(output, sa.counter)
+
+	return append(output, attested...)
+}
+
+func (sa *softAuthenticator) create(options CreationOptions) RegistrationResponse {
+	point, _ := sa.key.PublicKey.Bytes()
+
+	coseKey := encodeMap(
+		encodeInt(coseKeyType), encodeInt(keyTypeEC2),
+		encodeInt(coseAlgorithm), encodeInt(algES256),
+		encodeInt(coseCurve), encodeInt(curveP256),
+		encodeInt(coseX), encodeBytes(point[1:33]),
+		encodeInt(coseY), encodeBytes(point[33:]),
+	)
+
+	attested := make([]byte, 16)
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(sa.id)))
+	attested = append(attested, sa.id...)
+	attested = append(attested, coseKey...)
+
+	var response RegistrationResponse
+	response.ID = base64.RawURLEncoding.EncodeToString(sa.id)
+	response.RawID = sa.id
+	response.Type = "public-key"
+	response.Response.ClientDataJSON = sa.clientData(typeCreate, options.Challenge)
+	response.Response.AttestationObject = encodeMap(
+		encodeText("fmt"), encodeText("none"),
+		encodeText("attStmt"), encodeMap(),
+		encodeText("authData"), encodeBytes(sa.authData(flagUserPresent|flagUserVerified|flagAttestedData, attested)),
+	)
+
+	return response
+}
+
+func (sa *softAuthenticator) get(options RequestOptions) AssertionResponse {
+	sa.counter++
+
+	var response AssertionResponse
+	response.ID = base64.RawURLEncoding.EncodeToString(sa.id)
+	response.RawID = sa.id
+	response.Type = "public-key"
+	response.Response.ClientDataJSON = sa.clientData(typeGet, options.Challenge)
+	response.Response.AuthenticatorData = sa.authData(flagUserPresent|flagUserVerified, nil)
+
+	clientDataHash := sha256.Sum256(response.Response.ClientDataJSON)
+	digest := sha256.Sum256(append(append([]byte{}, response.Response.AuthenticatorData...), clientDataHash[:]...))
+
+	response.Response.Signature, _ = ecdsa.SignASN1(rand.Reader, sa.key, digest[:])
+
+	return response
+}
+
+func encodeHead(major byte, length int) []byte {
+	switch {
+	case length < 24:
+		return []byte{major<<5 | byte(length)}
+	case length < 256:
+		return []byte{major<<5 | 24, byte(length)}
+	default:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(length))
+	}
+}
+
+func encodeInt(value int) []byte {
+	if value < 0 {
+		return encodeHead(1, -1-value)
+	}
+
+	return encodeHead(0, value)
+}
+
+func encodeBytes(value []byte) []byte {
+	return append(encodeHead(2, len(value)), value...)
+}
+
+func encodeText(value string) []byte {
+	return append(encodeHead(3, len(value)), value...)
+}
+
+func encodeMap(pairs ...[]byte) []byte {
+	output := encodeHead(5, len(pairs)/2)
+
+	for _, item := range pairs {
+		output = append(output, item...)
+	}
+
+	return output
+}
+
+func newTestService(t *testing.T) (Service, *testStorage) {
+	t.Helper()
+
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	config := Flags(fs, "")
+
+	if err := fs.Parse(nil); err != nil {
+		t.Fatalf("parse flags: %s", err)
+	}
+
+	storage := &testStorage{credentials: make(map[string]model.WebAuthnCredential)}
+
+	return New(config, fake.NewCache(), storage, nil, cookie.Service[model.OAuthClaim]{}, redirect.Service{}), storage
+}
+
+func register(t *testing.T, instance Service, user model.User) *softAuthenticator {
+	t.Helper()
+
+	ctx := context.Background()
+	authenticator := newSoftAuthenticator(t, instance.rpID, instance.origin)
+
+	options, err := instance.BeginRegistration(ctx, user)
+	if err != nil {
+		t.Fatalf("begin registration: %s", err)
+	}
+
+	if _, err := instance.FinishRegistration(ctx, user, authenticator.create(options), "test"); err != nil {
+		t.Fatalf("finish registration: %s", err)
+	}
+
+	return authenticator
+}
+
+func TestRegistrationAndLogin(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	instance, storage := newTestService(t)
+	user := model.NewUser("alice")
+
+	authenticator := register(t, instance, user)
+
+	if required, err := instance.Required(ctx, user); err != nil || !required {
+		t.Errorf("Required() = (%t, `%s`), want (true, nil)", required, err)
+	}
+
+	options, err := instance.BeginLogin(ctx, model.User{})
+	if err != nil {
+		t.Fatalf("begin login: %s", err)
+	}
+
+	response := authenticator.get(options)
+
+	got, err := instance.Authenticate(ctx, response)
+	if err != nil || got.ID != user.ID {
+		t.Errorf("Authenticate() = (%+v, `%s`), want (%+v, nil)", got, err, user)
+	}
+
+	if _, err := instance.Authenticate(ctx, response); !errors.Is(err, ErrInvalidCredential) {
+		t.Errorf("Authenticate() replay = `%v`, want `%s`", err, ErrInvalidCredential)
+	}
+
+	if credential := storage.credentials[string(authenticator.id)]; credential.SignCount != 1 || credential.LastUsed.IsZero() {
+		t.Errorf("credential = %+v, want counter updated", credential)
+	}
+}
+
+func TestConsumeChallenge(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	instance, _ := newTestService(t)
+
+	challenge, err := instance.newChallenge(ctx, challengeState{Type: typeGet})
+	if err != nil {
+		t.Fatalf("new challenge: %s", err)
+	}
+
+	if _, err := instance.cache.Exclusive(ctx, challengeKey(challenge)+":consume", time.Second, func(ctx context.Context) error {
+		_, err := instance.consumeChallenge(ctx, challenge, typeGet)
+		return err
+	}); !errors.Is(err, ErrInvalidCredential) {
+		t.Errorf("consumeChallenge() in use = `%v`, want `%s`", err, ErrInvalidCredential)
+	}
+
+	if _, err := instance.consumeChallenge(ctx, challenge, typeGet); err != nil {
+		t.Errorf("consumeChallenge() = `%s`", err)
+	}
+
+	if _, err := instance.consumeChallenge(ctx, challenge, typeGet); !errors.Is(err, ErrInvalidCredential) {
+		t.Errorf("consumeChallenge() replay = `%v`, want `%s`", err, ErrInvalidCredential)
+	}
+}
+
+func TestFinishLogin(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		tamper  func(*softAuthenticator, *AssertionResponse)
+		wantErr error
+	}{
+		"valid": {
+			func(*softAuthenticator, *AssertionResponse) {},
+			nil,
+		},
+		"wrong origin": {
+			func(sa *softAuthenticator, response *AssertionResponse) {
+				var content clientData
+				_ = json.Unmarshal(response.Response.ClientDataJSON, &content)
+				content.Origin = "https://evil.example"
+				response.Response.ClientDataJSON, _ = json.Marshal(content)
+			},
+			ErrInvalidCredential,
+		},
+		"unknown challenge": {
+			func(sa *softAuthenticator, response *AssertionResponse) {
+				*response = sa.get(RequestOptions{Challenge: []byte("forged challenge")})
+			},
+			ErrInvalidCredential,
+		},
+		"wrong relying party": {
+			func(sa *softAuthenticator, response *AssertionResponse) {
+				var content clientData
+				_ = json.Unmarshal(response.Response.ClientDataJSON, &content)
+				challenge, _ := base64.RawURLEncoding.DecodeString(content.Challenge)
+
+				sa.rpID = "evil.example"
+				*response = sa.get(RequestOptions{Challenge: challenge})
+			},
+			ErrInvalidCredential,
+		},
+		"invalid signature": {
+			func(_ *softAuthenticator, response *AssertionResponse) {
+				response.Response.Signature[len(response.Response.Signature)-1] ^= 0xff
+			},
+			ErrInvalidCredential,
+		},
+		"cloned authenticator": {
+			func(sa *softAuthenticator, response *AssertionResponse) {
+				var content clientData
+				_ = json.Unmarshal(response.Response.ClientDataJSON, &content)
+				challenge, _ := base64.RawURLEncoding.DecodeString(content.Challenge)
+
+				sa.counter = 0
+				*response = sa.get(RequestOptions{Challenge: challenge})
+			},
+			ErrClonedAuthenticator,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			instance, storage := newTestService(t)
+			authenticator := register(t, instance, model.NewUser("bob"))
+
+			credential := storage.credentials[string(authenticator.id)]
+			credential.SignCount = 5
+			storage.credentials[string(authenticator.id)] = credential
+			authenticator.counter = 5
+
+			options, err := instance.BeginLogin(ctx, model.User{})
+			if err != nil {
+				t.Fatalf("begin login: %s", err)
+			}
+
+			response := authenticator.get(options)
+			testCase.tamper(authenticator, &response)
+
+			if _, gotErr := instance.FinishLogin(ctx, response); !errors.Is(gotErr, testCase.wantErr) {
+				t.Errorf("FinishLogin() = `%v`, want `%v`", gotErr, testCase.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	instance, _ := newTestService(t)
+
+	owner := model.NewUser("carol")
+	other := model.NewUser("dave")
+
+	authenticator := register(t, instance, owner)
+
+	cases := map[string]struct {
+		user    model.User
+		wantErr error
+	}{
+		"other user": {
+			other,
+			ErrInvalidCredential,
+		},
+		"owner": {
+			owner,
+			nil,
+		},
+	}
+
+	for _, intention := range []string{"other user", "owner"} {
+		testCase := cases[intention]
+
+		options, err := instance.BeginLogin(ctx, testCase.user)
+		if err != nil {
+			t.Fatalf("begin login: %s", err)
+		}
+
+		code, _ := json.Marshal(authenticator.get(options))
+
+		if gotErr := instance.Verify(ctx, testCase.user, string(code)); !errors.Is(gotErr, testCase.wantErr) {
+			t.Errorf("%s: Verify() = `%v`, want `%v`", intention, gotErr, testCase.wantErr)
+		}
+	}
+}
+
+func TestFinishRegistration(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	instance, _ := newTestService(t)
+
+	user := model.NewUser("erin")
+	authenticator := newSoftAuthenticator(t, instance.rpID, instance.origin)
+
+	options, err := instance.BeginRegistration(ctx, user)
+	if err != nil {
+		t.Fatalf("begin registration: %s", err)
+	}
+
+	if _, err := instance.FinishRegistration(ctx, model.NewUser("mallory"), authenticator.create(options), ""); !errors.Is(err, ErrInvalidCredential) {
+		t.Errorf("FinishRegistration() other user = `%v`, want `%s`", err, ErrInvalidCredential)
+	}
+
+	options, _ = instance.BeginRegistration(ctx, user)
+	if _, err := instance.FinishRegistration(ctx, user, authenticator.create(options), ""); err != nil {
+		t.Errorf("FinishRegistration() = `%s`", err)
+	}
+
+	options, _ = instance.BeginRegistration(ctx, user)
+	if len(options.ExcludeCredentials) != 1 {
+		t.Errorf("ExcludeCredentials = %d, want 1", len(options.ExcludeCredentials))
+	}
+
+	if _, err := instance.FinishRegistration(ctx, user, authenticator.create(options), ""); !errors.Is(err, ErrInvalidCredential) {
+		t.Errorf("FinishRegistration() duplicate = `%v`, want `%s`", err, ErrInvalidCredential)
+	}
+}
+
+type testBasicProvider struct {
+	user model.User
+}
+
+func (tbp testBasicProvider) GetBasicUser(_ context.Context, login, password string) (model.User, error) {
+	if login == tbp.user.Name && password == "secret" {
+		return tbp.user, nil
+	}
+
+	return model.User{}, model.ErrInvalidCredentials
+}
+
+func newFormRequest(target string, form url.Values, cookies []*http.Cookie) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	for _, item := range cookies {
+		req.AddCookie(item)
+	}
+
+	return req
+}
+
+func TestSecondFactor(t *testing.T) {
+	t.Parallel()
+
+	instance, _ := newTestService(t)
+	user := model.NewUser("alice")
+
+	authenticator := register(t, instance, user)
+
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	cookieConfig := cookie.Flags(fs, "")
+
+	if err := fs.Parse([]string{"-hmacSecret", "secret"}); err != nil {
+		t.Fatalf("parse cookie flags: %s", err)
+	}
+
+	identProvider := basic.New(testBasicProvider{user}, basic.WithCookie(cookie.New[model.User](cookieConfig)), basic.WithSecondFactor(instance))
+	begin := instance.BeginSecondFactor(identProvider.PendingUser)
+
+	writer := httptest.NewRecorder()
+	begin(writer, newFormRequest("/login/webauthn/begin", nil, nil))
+
+	if writer.Code != http.StatusUnauthorized {
+		t.Errorf("BeginSecondFactor() without password = %d, want %d", writer.Code, http.StatusUnauthorized)
+	}
+
+	writer = httptest.NewRecorder()
+	identProvider.Login(writer, newFormRequest("/login", url.Values{"login": {"alice"}, "password": {"secret"}}, nil))
+
+	if writer.Code != http.StatusUnauthorized || writer.Header().Get("X-OTP") != "required" {
+		t.Fatalf("Login() = %d `%s`, want a second factor required", writer.Code, writer.Header().Get("X-OTP"))
+	}
+
+	pending := writer.Result().Cookies()
+
+	writer = httptest.NewRecorder()
+	begin(writer, newFormRequest("/login/webauthn/begin", nil, pending))
+
+	var options RequestOptions
+	if err := json.Unmarshal(writer.Body.Bytes(), &options); err != nil {
+		t.Fatalf("BeginSecondFactor() = %d `%s`", writer.Code, writer.Body.String())
+	}
+
+	if len(options.AllowCredentials) != 1 {
+		t.Errorf("BeginSecondFactor() allowed %d credentials, want 1", len(options.AllowCredentials))
+	}
+
+	assertion, err := json.Marshal(authenticator.get(options))
+	if err != nil {
+		t.Fatalf("marshal assertion: %s", err)
+	}
+
+	writer = httptest.NewRecorder()
+	identProvider.LoginSecondFactor(writer, newFormRequest("/login/otp", url.Values{"otp": {string(assertion)}}, pending))
+
+	if writer.Code != http.StatusNoContent {
+		t.Errorf("LoginSecondFactor() = %d `%s`, want %d", writer.Code, writer.Body.String(), http.StatusNoContent)
+	}
+
+	writer = httptest.NewRecorder()
+	identProvider.LoginSecondFactor(writer, newFormRequest("/login/otp", url.Values{"otp": {string(assertion)}}, pending))
+
+	if writer.Code != http.StatusUnauthorized {
+		t.Errorf("LoginSecondFactor() replay = %d, want %d", writer.Code, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,225 @@
+package recovery
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ViBiOh/auth/v3/pkg/argon"
+	"github.com/ViBiOh/auth/v3/pkg/model"
+	"github.com/ViBiOh/httputils/v4/pkg/httperror"
+	"github.com/ViBiOh/httputils/v4/pkg/httpjson"
+)
+
+const (
+	count      = 10
+	codeLength = 10
+	alphabet   = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+var ErrInvalidCode = errors.New("invalid recovery code")
+
+type Storage interface {
+	ListRecoveryCodes(ctx context.Context, user model.User) ([]model.RecoveryCode, error)
+	UseRecoveryCode(ctx context.Context, code model.RecoveryCode) error
+	ReplaceRecoveryCodes(ctx context.Context, user model.User, hashes []string) error
+}
+
+type SecondFactor interface {
+	Required(ctx context.Context, user model.User) (bool, error)
+	Verify(ctx context.Context, user model.User, code string) error
+}
+
+type Service struct {
+	storage Storage
+	hasher  argon.Hasher
+}
+
+type Status struct {
+	Codes     []string `json:"codes,omitempty"`
+	Remaining int      `json:"remaining"`
+}
+
+func New(storage Storage, hasher argon.Hasher) Service {
+	return Service{
+		storage: storage,
+		hasher:  hasher,
+	}
+}
+
+func (s Service) Generate(ctx context.Context, user model.User) ([]string, error) {
+	codes := make([]string, count)
+	hashes := make([]string, count)
+
+	for i := range count {
+		code, err := generateCode()
+		if err != nil {
+			return nil, err
+		}
+
+		hash, err := s.hasher.GenerateFromPassword(ctx, normalize(code))
+		if err != nil {
+			return nil, fmt.Errorf("hash: %w", err)
+		}
+
+		codes[i] = code
+		hashes[i] = hash
+	}
+
+	if err := s.storage.ReplaceRecoveryCodes(ctx, user, hashes); err != nil {
+		return nil, fmt.Errorf("save: %w", err)
+	}
+
+	return codes, nil
+}
+
+func (s Service) Remaining(ctx context.Context, user model.User) (int, error) {
+	codes, err := s.storage.ListRecoveryCodes(ctx, user)
+	if err != nil {
+		return 0, fmt.Errorf("list: %w", err)
+	}
+
+	var remaining int
+
+	for _, code := range codes {
+		if code.Used.IsZero() {
+			remaining++
+		}
+	}
+
+	return remaining, nil
+}
+
+func (s Service) Verify(ctx context.Context, user model.User, code string) error {
+	code = normalize(code)
+	if len(code) != codeLength {
+		return ErrInvalidCode
+	}
+
+	codes, err := s.storage.ListRecoveryCodes(ctx, user)
+	if err != nil {
+		return fmt.Errorf("list: %w", err)
+	}
+
+	for _, item := range codes {
+		if !item.Used.IsZero() {
+			continue
+		}
+
+		if err := s.hasher.CompareHashAndPassword(ctx, item.Hash, code); err != nil {
+			if errors.Is(err, argon.ErrHashDontMatch) {
+				continue
+			}
+
+			return fmt.Errorf("compare: %w", err)
+		}
+
+		if err := s.storage.UseRecoveryCode(ctx, item); err != nil {
+			if errors.Is(err, model.ErrInvalidCredentials) {
+				return ErrInvalidCode
+			}
+
+			return fmt.Errorf("use: %w", err)
+		}
+
+		return nil
+	}
+
+	return ErrInvalidCode
+}
+
+func (s Service) Fallback(primary SecondFactor) SecondFactor {
+	return fallback{
+		primary:  primary,
+		recovery: s,
+	}
+}
+
+type fallback struct {
+	primary  SecondFactor
+	recovery Service
+}
+
+func (f fallback) Required(ctx context.Context, user model.User) (bool, error) {
+	return f.primary.Required(ctx, user)
+}
+
+func (f fallback) Verify(ctx context.Context, user model.User, code string) error {
+	err := f.primary.Verify(ctx, user, code)
+	if err == nil || len(normalize(code)) != codeLength {
+		return err
+	}
+
+	if recoveryErr := f.recovery.Verify(ctx, user, code); recoveryErr != nil {
+		return errors.Join(err, recoveryErr)
+	}
+
+	return nil
+}
+
+func (s Service) Mux(prefix string, mux *http.ServeMux) {
+	mux.HandleFunc("GET "+prefix, s.handleStatus)
+	mux.HandleFunc("POST "+prefix+"/regenerate", s.handleRegenerate)
+}
+
+func (s Service) handleStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user := model.ReadUser(ctx)
+	if len(user.ID) == 0 {
+		httperror.Unauthorized(ctx, w, errors.New("login is required"))
+		return
+	}
+
+	remaining, err := s.Remaining(ctx, user)
+	if err != nil {
+		httperror.InternalServerError(ctx, w, err)
+		return
+	}
+
+	httpjson.Write(ctx, w, http.StatusOK, Status{Remaining: remaining})
+}
+
+func (s Service) handleRegenerate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user := model.ReadUser(ctx)
+	if len(user.ID) == 0 {
+		httperror.Unauthorized(ctx, w, errors.New("login is required"))
+		return
+	}
+
+	codes, err := s.Generate(ctx, user)
+	if err != nil {
+		httperror.InternalServerError(ctx, w, err)
+		return
+	}
+
+	httpjson.Write(ctx, w, http.StatusOK, Status{Codes: codes, Remaining: len(codes)})
+}
+
+func generateCode() (string, error) {
+	raw := make([]byte, codeLength)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("generate: %w", err)
+	}
+
+	var builder strings.Builder
+
+	for i, value := range raw {
+		if i == codeLength/2 {
+			builder.WriteByte('-')
+		}
+
+		builder.WriteByte(alphabet[int(value)%len(alphabet)])
+	}
+
+	return builder.String(), nil
+}
+
+func normalize(code string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -0,0 +1,130 @@
+package recovery
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ViBiOh/auth/v3/pkg/argon"
+	"github.com/ViBiOh/auth/v3/pkg/model"
+)
+
+type testStorage struct {
+	codes []model.RecoveryCode
+	mutex sync.Mutex
+}
+
+func (ts *testStorage) ListRecoveryCodes(_ context.Context, _ model.User) ([]model.RecoveryCode, error) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	return slices.Clone(ts.codes), nil
+}
+
+func (ts *testStorage) UseRecoveryCode(_ context.Context, code model.RecoveryCode) error {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	for i := range ts.codes {
+		if ts.codes[i].ID == code.ID && ts.codes[i].Used.IsZero() {
+			ts.codes[i].Used = time.Now()
+
+			return nil
+		}
+	}
+
+	return model.ErrInvalidCredentials
+}
+
+func (ts *testStorage) ReplaceRecoveryCodes(_ context.Context, _ model.User, hashes []string) error {
+	ts.codes = ts.codes[:0]
+
+	for i, hash := range hashes {
+		ts.codes = append(ts.codes, model.RecoveryCode{ID: string(rune('a' + i)), Hash: hash})
+	}
+
+	return nil
+}
+
+type testSecondFactor struct{}
+
+func (tsf testSecondFactor) Required(_ context.Context, _ model.User) (bool, error) {
+	return true, nil
+}
+
+func (tsf testSecondFactor) Verify(_ context.Context, _ model.User, code string) error {
+	if code == "123456" {
+		return nil
+	}
+
+	return errors.New("invalid totp")
+}
+
+func TestService(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	user := model.User{ID: "1"}
+
+	instance := New(&testStorage{}, argon.DefaultHasher)
+
+	codes, err := instance.Generate(ctx, user)
+	if err != nil {
+		t.Fatalf("Generate() = `%s`", err)
+	}
+
+	if len(codes) != count {
+		t.Fatalf("Generate() = %d codes, want %d", len(codes), count)
+	}
+
+	secondFactor := instance.Fallback(testSecondFactor{})
+
+	if err := secondFactor.Verify(ctx, user, "123456"); err != nil {
+		t.Errorf("Verify() = `%s`", err)
+	}
+
+	if err := secondFactor.Verify(ctx, user, codes[3]); err != nil {
+		t.Errorf("Verify() = `%s`", err)
+	}
+
+	if err := secondFactor.Verify(ctx, user, codes[3]); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("Verify() = `%s`, want `%s`", err, ErrInvalidCode)
+	}
+
+	var wg sync.WaitGroup
+	results := make(chan error, 2)
+
+	for range 2 {
+		wg.Go(func() {
+			results <- instance.Verify(ctx, user, codes[5])
+		})
+	}
+
+	wg.Wait()
+	close(results)
+
+	var accepted int
+	for err := range results {
+		switch {
+		case err == nil:
+			accepted++
+		case !errors.Is(err, ErrInvalidCode):
+			t.Errorf("Verify() = `%s`, want `%s`", err, ErrInvalidCode)
+		}
+	}
+
+	if accepted != 1 {
+		t.Errorf("Verify() accepted the same code %d times, want 1", accepted)
+	}
+
+	if remaining, _ := instance.Remaining(ctx, user); remaining != count-2 {
+		t.Errorf("Remaining() = %d, want %d", remaining, count-2)
+	}
+
+	if err := secondFactor.Verify(ctx, user, "654321"); err == nil {
+		t.Error("Verify() = nil, want an error")
+	}
+}
@@ -0,0 +1,137 @@
+package redirect
+
+import (
+	"flag"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/ViBiOh/flags"
+)
+
+type rule struct {
+	host   string
+	prefix string
+}
+
+type Service struct {
+	rules []rule
+}
+
+type Config struct {
+	allowed []string
+}
+
+func Flags(fs *flag.FlagSet, prefix string, overrides ...flags.Override) *Config {
+	var config Config
+
+	flags.New("Allowed", "Allowed redirection targets, as host, host with path prefix or path prefix, e.g. 'example.com/app' or '/app'").Prefix(prefix).DocPrefix("redirect").StringSliceVar(fs, &config.allowed, nil, overrides)
+
+	return &config
+}
+
+func New(config *Config) Service {
+	var service Service
+
+	for _, allowed := range config.allowed {
+		allowed = strings.TrimSpace(allowed)
+		if len(allowed) == 0 {
+			continue
+		}
+
+		if strings.HasPrefix(allowed, "/") {
+			service.rules = append(service.rules, rule{prefix: cleanPath(allowed)})
+			continue
+		}
+
+		if !strings.Contains(allowed, "://") {
+			allowed = "//" + allowed
+		}
+
+		allowedURL, err := url.Parse(allowed)
+		if err != nil || len(allowedURL.Host) == 0 {
+			continue
+		}
+
+		service.rules = append(service.rules, rule{
+			host:   strings.ToLower(allowedURL.Host),
+			prefix: cleanPath(allowedURL.Path),
+		})
+	}
+
+	return service
+}
+
+func (s Service) IsAllowed(target string) bool {
+	if len(target) == 0 || strings.ContainsAny(target, "\\\r\n\t") {
+		return false
+	}
+
+	targetURL, err := url.Parse(target)
+	if err != nil || len(targetURL.Opaque) != 0 || targetURL.User != nil {
+		return false
+	}
+
+	if len(targetURL.Scheme) == 0 && len(targetURL.Host) == 0 {
+		return strings.HasPrefix(target, "/") && s.matchRelative(targetURL.Path)
+	}
+
+	if targetURL.Scheme != "https" && targetURL.Scheme != "http" {
+		return false
+	}
+
+	host := strings.ToLower(targetURL.Host)
+	pathname := cleanPath(targetURL.Path)
+
+	for _, item := range s.rules {
+		if item.host == host && hasPathPrefix(pathname, item.prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s Service) Sanitize(target, fallback string) string {
+	if s.IsAllowed(target) {
+		return target
+	}
+
+	return fallback
+}
+
+func (s Service) matchRelative(pathname string) bool {
+	pathname = cleanPath(pathname)
+
+	var hasPrefixRule bool
+
+	for _, item := range s.rules {
+		if len(item.host) != 0 {
+			continue
+		}
+
+		if hasPathPrefix(pathname, item.prefix) {
+			return true
+		}
+
+		hasPrefixRule = true
+	}
+
+	return !hasPrefixRule
+}
+
+func cleanPath(pathname string) string {
+	if len(pathname) == 0 {
+		return "/"
+	}
+
+	return path.Clean("/" + pathname)
+}
+
+func hasPathPrefix(pathname, prefix string) bool {
+	if len(prefix) == 0 || prefix == "/" {
+		return true
+	}
+
+	return pathname == prefix || strings.HasPrefix(pathname, prefix+"/")
+}
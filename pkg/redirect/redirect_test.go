@@ -0,0 +1,114 @@
+package redirect
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestIsAllowed(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		allowed []string
+		target  string
+		want    bool
+	}{
+		"empty": {
+			nil,
+			"",
+			false,
+		},
+		"relative": {
+			nil,
+			"/hello/world?name=vibioh",
+			true,
+		},
+		"relative without slash": {
+			nil,
+			"hello/world",
+			false,
+		},
+		"protocol relative": {
+			nil,
+			"//evil.com/hello",
+			false,
+		},
+		"backslash": {
+			nil,
+			"/\\evil.com",
+			false,
+		},
+		"javascript": {
+			[]string{"evil.com"},
+			"javascript:alert(1)",
+			false,
+		},
+		"absolute not listed": {
+			nil,
+			"https://evil.com/hello",
+			false,
+		},
+		"absolute listed": {
+			[]string{"example.com"},
+			"https://Example.com/hello",
+			true,
+		},
+		"absolute with credentials": {
+			[]string{"example.com"},
+			"https://user@example.com/hello",
+			false,
+		},
+		"absolute prefix": {
+			[]string{"https://example.com/app"},
+			"https://example.com/app/hello",
+			true,
+		},
+		"absolute outside prefix": {
+			[]string{"example.com/app"},
+			"https://example.com/application",
+			false,
+		},
+		"absolute traversal": {
+			[]string{"example.com/app"},
+			"https://example.com/app/../admin",
+			false,
+		},
+		"relative prefix": {
+			[]string{"/app"},
+			"/app/hello",
+			true,
+		},
+		"relative outside prefix": {
+			[]string{"/app", "example.com"},
+			"/admin",
+			false,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			instance := New(&Config{allowed: testCase.allowed})
+
+			if got := instance.IsAllowed(testCase.target); got != testCase.want {
+				t.Errorf("IsAllowed(`%s`) = %t, want %t", testCase.target, got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	t.Parallel()
+
+	fs := flag.NewFlagSet("sanitize", flag.ContinueOnError)
+	instance := New(Flags(fs, ""))
+
+	if got := instance.Sanitize("https://evil.com", "/"); got != "/" {
+		t.Errorf("Sanitize() = `%s`, want `%s`", got, "/")
+	}
+
+	if got := instance.Sanitize("/hello", "/"); got != "/hello" {
+		t.Errorf("Sanitize() = `%s`, want `%s`", got, "/hello")
+	}
+}
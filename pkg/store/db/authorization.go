@@ -2,19 +2,28 @@ package db
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"strings"
 
 	"github.com/ViBiOh/auth/v3/pkg/model"
+	"github.com/ViBiOh/httputils/v4/pkg/id"
 	"github.com/jackc/pgx/v5"
 )
 
+var (
+	ErrProfileExists  = errors.New("profile already exists")
+	ErrUnknownProfile = errors.New("unknown profile")
+)
+
 const readLoginProfile = `
 SELECT
   p.id
 FROM
   auth.profile p,
   auth.user_profile up
-WHER
+WHERE
   p.name = $2
   AND up.profile_id = p.id
   AND up.user_id = $1
@@ -39,3 +48,132 @@ func (s Service) IsAuthorized(ctx context.Context, user model.User, profile stri
 
 	return true
 }
+
+const createProfileQuery = `
+INSERT INTO
+  auth.profile
+(
+  id,
+  name
+)
+SELECT
+  $1,
+  $2
+WHERE
+  NOT EXISTS (SELECT 1 FROM auth.profile WHERE name = $2)
+RETURNING
+  id
+`
+
+func (s Service) CreateProfile(ctx context.Context, name string) error {
+	return s.db.Get(ctx, func(row pgx.Row) error {
+		var profileID string
+		err := row.Scan(&profileID)
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrProfileExists
+		}
+
+		return err
+	}, createProfileQuery, id.New(), name)
+}
+
+const unknownProfilesQuery = `
+SELECT
+  array_agg(wanted.name)
+FROM
+  unnest($1::TEXT[]) AS wanted(name)
+WHERE
+  NOT EXISTS (SELECT 1 FROM auth.profile p WHERE p.name = wanted.name)
+`
+
+func (s Service) checkProfiles(ctx context.Context, profiles []string) error {
+	var unknown []string
+
+	if err := s.db.Get(ctx, func(row pgx.Row) error {
+		return row.Scan(&unknown)
+	}, unknownProfilesQuery, profiles); err != nil {
+		return fmt.Errorf("check profiles: %w", err)
+	}
+
+	if len(unknown) != 0 {
+		return fmt.Errorf("%w: %s", ErrUnknownProfile, strings.Join(unknown, ", "))
+	}
+
+	return nil
+}
+
+const grantProfilesQuery = `
+INSERT INTO
+  auth.user_profile
+(
+  user_id,
+  profile_id
+)
+SELECT
+  $1,
+  p.id
+FROM
+  auth.profile p
+WHERE
+  p.name = ANY($2)
+ON CONFLICT (user_id, profile_id) DO NOTHING
+`
+
+const revokeProfilesQuery = `
+DELETE FROM
+  auth.user_profile up
+USING
+  auth.profile p
+WHERE
+  up.profile_id = p.id
+  AND up.user_id = $1
+  AND p.name = ANY($2)
+  AND NOT p.name = ANY($3)
+`
+
+func (s Service) GrantProfiles(ctx context.Context, user model.User, profiles []string) error {
+	if len(profiles) == 0 {
+		return nil
+	}
+
+	if err := s.checkProfiles(ctx, profiles); err != nil {
+		return err
+	}
+
+	return s.db.Exec(ctx, grantProfilesQuery, user.ID, profiles)
+}
+
+func (s Service) SyncProfiles(ctx context.Context, user model.User, managed, granted []string) error {
+	if granted == nil {
+		granted = []string{}
+	}
+
+	return s.db.DoAtomic(ctx, func(ctx context.Context) error {
+		if err := s.db.Exec(ctx, revokeProfilesQuery, user.ID, managed, granted); err != nil {
+			return fmt.Errorf("revoke: %w", err)
+		}
+
+		if len(granted) == 0 {
+			return nil
+		}
+
+		if err := s.db.Exec(ctx, grantProfilesQuery, user.ID, granted); err != nil {
+			return fmt.Errorf("grant: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (s Service) RevokeProfiles(ctx context.Context, user model.User, profiles []string) error {
+	if len(profiles) == 0 {
+		return nil
+	}
+
+	if err := s.checkProfiles(ctx, profiles); err != nil {
+		return err
+	}
+
+	return s.db.Exec(ctx, revokeProfilesQuery, user.ID, profiles, []string{})
+}
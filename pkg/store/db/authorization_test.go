@@ -71,3 +71,159 @@ func TestIsAuthorized(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateProfile(t *testing.T) {
+	t.Parallel()
+
+	errTimeout := errors.New("timeout")
+
+	cases := map[string]struct {
+		scanErr error
+		wantErr error
+	}{
+		"create": {
+			nil,
+			nil,
+		},
+		"exists": {
+			pgx.ErrNoRows,
+			ErrProfileExists,
+		},
+		"error": {
+			errTimeout,
+			errTimeout,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+
+			mockDatabase := mocks.NewDatabase(ctrl)
+
+			instance := Service{db: mockDatabase}
+
+			mockRow := mocks.NewRow(ctrl)
+			mockRow.EXPECT().Scan(gomock.Any()).Return(testCase.scanErr)
+
+			mockDatabase.EXPECT().Get(gomock.Any(), gomock.Any(), createProfileQuery, gomock.Any(), "admin").DoAndReturn(func(_ context.Context, scanner func(pgx.Row) error, _ string, _ ...any) error {
+				return scanner(mockRow)
+			})
+
+			if gotErr := instance.CreateProfile(context.Background(), "admin"); !errors.Is(gotErr, testCase.wantErr) {
+				t.Errorf("CreateProfile() = `%v`, want `%v`", gotErr, testCase.wantErr)
+			}
+		})
+	}
+}
+
+func expectUnknownProfiles(ctrl *gomock.Controller, mockDatabase *mocks.Database, profiles, unknown []string) {
+	mockRow := mocks.NewRow(ctrl)
+	mockRow.EXPECT().Scan(gomock.Any()).DoAndReturn(func(pointers ...any) error {
+		*pointers[0].(*[]string) = unknown
+
+		return nil
+	})
+
+	mockDatabase.EXPECT().Get(gomock.Any(), gomock.Any(), unknownProfilesQuery, profiles).DoAndReturn(func(_ context.Context, scanner func(pgx.Row) error, _ string, _ ...any) error {
+		return scanner(mockRow)
+	})
+}
+
+func TestGrantProfiles(t *testing.T) {
+	t.Parallel()
+
+	user := model.NewUser("vibioh")
+
+	cases := map[string]struct {
+		profiles []string
+		wantErr  error
+	}{
+		"grant": {
+			[]string{"admin"},
+			nil,
+		},
+		"unknown": {
+			[]string{"admin", "admni"},
+			ErrUnknownProfile,
+		},
+		"nothing": {
+			nil,
+			nil,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+
+			mockDatabase := mocks.NewDatabase(ctrl)
+
+			instance := Service{db: mockDatabase}
+
+			switch intention {
+			case "grant":
+				expectUnknownProfiles(ctrl, mockDatabase, testCase.profiles, nil)
+				mockDatabase.EXPECT().Exec(gomock.Any(), grantProfilesQuery, user.ID, testCase.profiles).Return(nil)
+			case "unknown":
+				expectUnknownProfiles(ctrl, mockDatabase, testCase.profiles, []string{"admni"})
+			}
+
+			if gotErr := instance.GrantProfiles(context.Background(), user, testCase.profiles); !errors.Is(gotErr, testCase.wantErr) {
+				t.Errorf("GrantProfiles() = `%v`, want `%v`", gotErr, testCase.wantErr)
+			}
+		})
+	}
+}
+
+func TestRevokeProfiles(t *testing.T) {
+	t.Parallel()
+
+	user := model.NewUser("vibioh")
+
+	cases := map[string]struct {
+		profiles []string
+		wantErr  error
+	}{
+		"revoke": {
+			[]string{"admin"},
+			nil,
+		},
+		"unknown": {
+			[]string{"admni"},
+			ErrUnknownProfile,
+		},
+		"nothing": {
+			nil,
+			nil,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+
+			mockDatabase := mocks.NewDatabase(ctrl)
+
+			instance := Service{db: mockDatabase}
+
+			switch intention {
+			case "revoke":
+				expectUnknownProfiles(ctrl, mockDatabase, testCase.profiles, nil)
+				mockDatabase.EXPECT().Exec(gomock.Any(), revokeProfilesQuery, user.ID, testCase.profiles, []string{}).Return(nil)
+			case "unknown":
+				expectUnknownProfiles(ctrl, mockDatabase, testCase.profiles, testCase.profiles)
+			}
+
+			if gotErr := instance.RevokeProfiles(context.Background(), user, testCase.profiles); !errors.Is(gotErr, testCase.wantErr) {
+				t.Errorf("RevokeProfiles() = `%v`, want `%v`", gotErr, testCase.wantErr)
+			}
+		})
+	}
+}
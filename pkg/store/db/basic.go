@@ -2,16 +2,17 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
 
-	"github.com/ViBiOh/auth/v3/pkg/argon"
 	"github.com/ViBiOh/auth/v3/pkg/model"
 	"github.com/jackc/pgx/v5"
-	"golang.org/x/crypto/bcrypt"
 )
 
+var ErrEmptyPassword = errors.New("empty password")
+
 const basicUserQuery = `
 SELECT
   u.id,
@@ -47,13 +48,33 @@ func (s Service) GetBasicUser(ctx context.Context, login, password string) (mode
 
 	switch {
 	case strings.HasPrefix(userPassword, "$argon2id"):
-		if argon.CompareHashAndPassword(userPassword, password) == nil {
+		err := s.hasher.CompareHashAndPassword(ctx, userPassword, password)
+		if errors.Is(err, model.ErrUnavailableService) {
+			slog.LogAttrs(ctx, slog.LevelWarn, "login", slog.String("login", login), slog.Any("error", err))
+			return model.User{}, err
+		}
+
+		if err == nil {
+			if s.hasher.NeedsRehash(userPassword) {
+				if err := s.upgradePassword(ctx, user, userPassword, password); err != nil {
+					slog.LogAttrs(ctx, slog.LevelError, "rehash argon2 password", slog.Any("error", err))
+				}
+			}
+
 			return user, nil
 		}
 
 	default:
-		if bcrypt.CompareHashAndPassword([]byte(userPassword), []byte(password)) == nil {
-			if err := s.UpdatePassword(ctx, user, password); err != nil {
+		err := s.hasher.Do(ctx, func() error {
+			return s.legacy.Verify(userPassword, password)
+		})
+		if errors.Is(err, model.ErrUnavailableService) {
+			slog.LogAttrs(ctx, slog.LevelWarn, "login", slog.String("login", login), slog.Any("error", err))
+			return model.User{}, err
+		}
+
+		if err == nil {
+			if err := s.upgradePassword(ctx, user, userPassword, password); err != nil {
 				slog.LogAttrs(ctx, slog.LevelError, "update password to argon2", slog.Any("error", err))
 			}
 
@@ -79,12 +100,16 @@ INSERT INTO
 `
 
 func (s Service) CreateBasic(ctx context.Context, login, password string) (model.User, error) {
+	if len(password) == 0 {
+		return model.User{}, ErrEmptyPassword
+	}
+
 	user, err := s.Create(ctx, login)
 	if err != nil {
 		return user, fmt.Errorf("create user: %w", err)
 	}
 
-	password, err = argon.GenerateFromPassword(password)
+	password, err = s.hasher.GenerateFromPassword(ctx, password)
 	if err != nil {
 		return user, fmt.Errorf("hash password: %w", err)
 	}
@@ -105,12 +130,51 @@ WHERE
 `
 
 func (s Service) UpdatePassword(ctx context.Context, user model.User, password string) error {
-	password, err := argon.GenerateFromPassword(password)
+	if len(password) == 0 {
+		return ErrEmptyPassword
+	}
+
+	password, err := s.hasher.GenerateFromPassword(ctx, password)
+	if err != nil {
+		return fmt.Errorf("hash password: %w", err)
+	}
+
+	if err := s.db.One(ctx, updatePasswordQuery, user.ID, password); err != nil {
+		return err
+	}
+
+	if s.onPasswordUpdate == nil {
+		return nil
+	}
+
+	if updated, ok := ctx.Value(passwordUpdatesKey{}).(*[]model.User); ok {
+		*updated = append(*updated, user)
+		return nil
+	}
+
+	s.onPasswordUpdate(ctx, user)
+
+	return nil
+}
+
+const upgradePasswordQuery = `
+UPDATE
+  auth.basic
+SET
+  password = $2
+WHERE
+  user_id = $1
+  AND password = $3
+`
+
+// upgradePassword doesn't overwrite a password changed since the verified hash was read
+func (s Service) upgradePassword(ctx context.Context, user model.User, previous, password string) error {
+	password, err := s.hasher.GenerateFromPassword(ctx, password)
 	if err != nil {
 		return fmt.Errorf("hash password: %w", err)
 	}
 
-	return s.db.One(ctx, updatePasswordQuery, user.ID, password)
+	return s.db.Exec(ctx, upgradePasswordQuery, user.ID, password, previous)
 }
 
 const listBasicQuery = `
@@ -134,7 +198,7 @@ func (s Service) listBasicUsers(ctx context.Context, userIDs ...string) ([]model
 		}
 
 		item.Kind = model.Basic
-		items = append(items, item)
+		items = append(items, withIdentity(item, item.Name))
 
 		return nil
 	}, listBasicQuery, userIDs)
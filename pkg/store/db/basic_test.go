@@ -6,6 +6,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/ViBiOh/auth/v3/pkg/argon"
 	"github.com/ViBiOh/auth/v3/pkg/mocks"
 	"github.com/ViBiOh/auth/v3/pkg/model"
 	"github.com/jackc/pgx/v5"
@@ -36,6 +37,14 @@ func TestLogin(t *testing.T) {
 			expectedUser,
 			nil,
 		},
+		"rehash": {
+			args{
+				login:    "vibioh",
+				password: "secret",
+			},
+			expectedUser,
+			nil,
+		},
 		"not found": {
 			args{
 				login:    "vibioh",
@@ -62,7 +71,7 @@ func TestLogin(t *testing.T) {
 
 			mockDatabase := mocks.NewDatabase(ctrl)
 
-			instance := Service{db: mockDatabase}
+			instance := New(mockDatabase)
 
 			switch intention {
 			case "simple":
@@ -79,6 +88,29 @@ func TestLogin(t *testing.T) {
 				}
 				mockDatabase.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any(), "vibioh").DoAndReturn(dummyFn)
 
+			case "rehash":
+				weakParams := argon.DefaultParams
+				weakParams.Iterations = 1
+
+				weakHash, err := weakParams.GenerateFromPassword("secret")
+				if err != nil {
+					t.Fatalf("generate: %s", err)
+				}
+
+				mockRow := mocks.NewRow(ctrl)
+				mockRow.EXPECT().Scan(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(pointers ...any) error {
+					*pointers[0].(*string) = expectedUser.ID
+					*pointers[1].(*string) = "vibioh"
+					*pointers[2].(*string) = weakHash
+
+					return nil
+				})
+				dummyFn := func(_ context.Context, scanner func(pgx.Row) error, _ string, _ ...any) error {
+					return scanner(mockRow)
+				}
+				mockDatabase.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any(), "vibioh").DoAndReturn(dummyFn)
+				mockDatabase.EXPECT().Exec(gomock.Any(), upgradePasswordQuery, expectedUser.ID, gomock.Any(), weakHash).Return(nil)
+
 			case "not found":
 				mockRow := mocks.NewRow(ctrl)
 				mockRow.EXPECT().Scan(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(pointers ...any) error {
@@ -128,6 +160,13 @@ func TestUpdatePassword(t *testing.T) {
 			},
 			nil,
 		},
+		"empty": {
+			args{
+				o:        model.NewUser("admin"),
+				password: "",
+			},
+			ErrEmptyPassword,
+		},
 	}
 
 	for intention, testCase := range cases {
@@ -138,7 +177,7 @@ func TestUpdatePassword(t *testing.T) {
 
 			mockDatabase := mocks.NewDatabase(ctrl)
 
-			instance := Service{db: mockDatabase}
+			instance := New(mockDatabase)
 
 			switch intention {
 			case "update":
@@ -161,3 +200,76 @@ func TestUpdatePassword(t *testing.T) {
 		})
 	}
 }
+
+func TestPasswordUpdateHandler(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		atomic      bool
+		actionErr   error
+		wantUpdates int
+	}{
+		"outside transaction": {
+			false,
+			nil,
+			1,
+		},
+		"committed": {
+			true,
+			nil,
+			1,
+		},
+		"rolled back": {
+			true,
+			errors.New("rollback"),
+			0,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+
+			mockDatabase := mocks.NewDatabase(ctrl)
+
+			var updates int
+			var inTransaction bool
+
+			instance := New(mockDatabase, WithPasswordUpdateHandler(func(context.Context, model.User) {
+				if inTransaction {
+					t.Error("password update handler called before commit")
+				}
+
+				updates++
+			}))
+
+			mockDatabase.EXPECT().One(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+			mockDatabase.EXPECT().DoAtomic(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, action func(context.Context) error) error {
+				inTransaction = true
+				defer func() { inTransaction = false }()
+
+				return action(ctx)
+			}).AnyTimes()
+
+			update := func(ctx context.Context) error {
+				if err := instance.UpdatePassword(ctx, model.NewUser("admin"), "secret"); err != nil {
+					return err
+				}
+
+				return testCase.actionErr
+			}
+
+			if testCase.atomic {
+				_ = instance.DoAtomic(context.Background(), update)
+			} else {
+				_ = update(context.Background())
+			}
+
+			if updates != testCase.wantUpdates {
+				t.Errorf("password update handler called %d times, want %d", updates, testCase.wantUpdates)
+			}
+		})
+	}
+}
@@ -2,14 +2,34 @@ package db
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"slices"
 
 	"github.com/ViBiOh/auth/v3/pkg/model"
 	"github.com/ViBiOh/httputils/v4/pkg/concurrent"
+	"github.com/jackc/pgx/v5"
 )
 
+type passwordUpdatesKey struct{}
+
+// DoAtomic notifies the password updates of the transaction once it's committed, so a concurrent login can't cache the previous password again
 func (s Service) DoAtomic(ctx context.Context, action func(context.Context) error) error {
-	return s.db.DoAtomic(ctx, action)
+	if s.onPasswordUpdate == nil || ctx.Value(passwordUpdatesKey{}) != nil {
+		return s.db.DoAtomic(ctx, action)
+	}
+
+	var updated []model.User
+
+	if err := s.db.DoAtomic(context.WithValue(ctx, passwordUpdatesKey{}, &updated), action); err != nil {
+		return err
+	}
+
+	for _, user := range updated {
+		s.onPasswordUpdate(ctx, user)
+	}
+
+	return nil
 }
 
 const insertQuery = `
@@ -29,10 +49,35 @@ func (s Service) Create(ctx context.Context, name string) (model.User, error) {
 	return user, s.db.One(ctx, insertQuery, user.ID)
 }
 
+const listIDsQuery = `
+SELECT
+  id
+FROM
+  auth.user
+ORDER BY
+  creation
+`
+
+func (s Service) ListIDs(ctx context.Context) ([]string, error) {
+	var ids []string
+
+	return ids, s.db.List(ctx, func(rows pgx.Rows) error {
+		var id string
+
+		if err := rows.Scan(&id); err != nil {
+			return fmt.Errorf("scan: %w", err)
+		}
+
+		ids = append(ids, id)
+
+		return nil
+	}, listIDsQuery)
+}
+
 func (s Service) List(ctx context.Context, ids ...string) ([]model.User, error) {
 	conc := concurrent.NewFailFast(0)
 
-	var discordUsers, githubUsers, googleUsers, basicUsers, inviteUsers []model.User
+	var discordUsers, githubUsers, googleUsers, emailUsers, basicUsers, inviteUsers []model.User
 
 	conc.Go(func() (err error) {
 		discordUsers, err = s.listDiscordUsers(ctx, ids...)
@@ -49,6 +94,11 @@ func (s Service) List(ctx context.Context, ids ...string) ([]model.User, error)
 		return err
 	})
 
+	conc.Go(func() (err error) {
+		emailUsers, err = s.listEmailUsers(ctx, ids...)
+		return err
+	})
+
 	conc.Go(func() (err error) {
 		basicUsers, err = s.listBasicUsers(ctx, ids...)
 		return err
@@ -61,7 +111,84 @@ func (s Service) List(ctx context.Context, ids ...string) ([]model.User, error)
 
 	err := conc.Wait()
 
-	return slices.Concat(discordUsers, githubUsers, googleUsers, basicUsers, inviteUsers), err
+	return mergeUsers(slices.Concat(discordUsers, githubUsers, googleUsers, emailUsers, basicUsers, inviteUsers)), err
+}
+
+func (s Service) ListIdentities(ctx context.Context, user model.User) ([]model.Identity, error) {
+	users, err := s.List(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range users {
+		if item.ID == user.ID {
+			return item.Identities, nil
+		}
+	}
+
+	return nil, nil
+}
+
+const countIdentitiesQuery = `
+SELECT
+  (SELECT COUNT(1) FROM auth.discord d WHERE d.user_id = u.id)
+  + (SELECT COUNT(1) FROM auth.github g WHERE g.user_id = u.id)
+  + (SELECT COUNT(1) FROM auth.google o WHERE o.user_id = u.id)
+  + (SELECT COUNT(1) FROM auth.email e WHERE e.user_id = u.id)
+  + (SELECT COUNT(1) FROM auth.basic b WHERE b.user_id = u.id)
+FROM
+  auth.user u
+WHERE
+  u.id = $1
+FOR UPDATE
+`
+
+func (s Service) CountIdentities(ctx context.Context, user model.User) (int, error) {
+	var count int
+
+	return count, s.db.Get(ctx, func(row pgx.Row) error {
+		err := row.Scan(&count)
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.ErrUnknownUser
+		}
+
+		return err
+	}, countIdentitiesQuery, user.ID)
+}
+
+func withIdentity(user model.User, id string) model.User {
+	user.Identities = []model.Identity{{
+		ID:    id,
+		Name:  user.Name,
+		Image: user.Image,
+		Kind:  user.Kind,
+	}}
+
+	return user
+}
+
+func mergeUsers(items []model.User) []model.User {
+	indexes := make(map[string]int, len(items))
+	output := make([]model.User, 0, len(items))
+
+	for _, item := range items {
+		index, ok := indexes[item.ID]
+		if !ok {
+			indexes[item.ID] = len(output)
+			output = append(output, item)
+
+			continue
+		}
+
+		output[index].Identities = append(output[index].Identities, item.Identities...)
+
+		if len(output[index].Email) == 0 {
+			output[index].Email = item.Email
+		}
+	}
+
+	return output
 }
 
 const deleteQuery = `
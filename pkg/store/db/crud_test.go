@@ -3,6 +3,8 @@ package db
 import (
 	"context"
 	"errors"
+	"reflect"
+	"slices"
 	"testing"
 
 	"github.com/ViBiOh/auth/v3/pkg/mocks"
@@ -101,3 +103,43 @@ func TestDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestMergeUsers(t *testing.T) {
+	t.Parallel()
+
+	github := withIdentity(model.User{ID: "1", Name: "vibioh", Kind: model.GitHub}, "42")
+	google := withIdentity(model.User{ID: "1", Name: "Vincent", Kind: model.Google}, "sub")
+	basic := withIdentity(model.User{ID: "2", Name: "admin", Kind: model.Basic}, "admin")
+	invite := model.User{ID: "3", Name: "nobody@localhost"}
+
+	merged := github
+	merged.Identities = append(slices.Clone(github.Identities), google.Identities...)
+
+	cases := map[string]struct {
+		items []model.User
+		want  []model.User
+	}{
+		"empty": {
+			nil,
+			[]model.User{},
+		},
+		"distinct": {
+			[]model.User{basic, invite},
+			[]model.User{basic, invite},
+		},
+		"merged": {
+			[]model.User{github, basic, google, invite},
+			[]model.User{merged, basic, invite},
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			if got := mergeUsers(testCase.items); !reflect.DeepEqual(got, testCase.want) {
+				t.Errorf("mergeUsers() = %+v, want %+v", got, testCase.want)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 
+	"github.com/ViBiOh/auth/v3/pkg/argon"
 	"github.com/ViBiOh/auth/v3/pkg/model"
 	"github.com/ViBiOh/auth/v3/pkg/provider/basic"
 	"github.com/jackc/pgx/v5"
@@ -14,12 +15,16 @@ type Database interface {
 	Get(context.Context, func(pgx.Row) error, string, ...any) error
 	Create(context.Context, string, ...any) (uint64, error)
 	One(context.Context, string, ...any) error
+	Exec(context.Context, string, ...any) error
 	List(context.Context, func(pgx.Rows) error, string, ...any) error
 	DoAtomic(context.Context, func(context.Context) error) error
 }
 
 type Service struct {
-	db Database
+	db               Database
+	onPasswordUpdate func(context.Context, model.User)
+	legacy           argon.Verifiers
+	hasher           argon.Hasher
 }
 
 var (
@@ -27,8 +32,42 @@ var (
 	_ basic.Provider = Service{}
 )
 
-func New(db Database) Service {
-	return Service{
-		db: db,
+type Option func(Service) Service
+
+func WithHasher(hasher argon.Hasher) Option {
+	return func(instance Service) Service {
+		instance.hasher = hasher
+
+		return instance
+	}
+}
+
+func WithLegacyVerifiers(verifiers argon.Verifiers) Option {
+	return func(instance Service) Service {
+		instance.legacy = verifiers
+
+		return instance
+	}
+}
+
+func WithPasswordUpdateHandler(handler func(context.Context, model.User)) Option {
+	return func(instance Service) Service {
+		instance.onPasswordUpdate = handler
+
+		return instance
+	}
+}
+
+func New(db Database, options ...Option) Service {
+	service := Service{
+		db:     db,
+		legacy: argon.LegacyVerifiers,
+		hasher: argon.DefaultHasher,
+	}
+
+	for _, option := range options {
+		service = option(service)
 	}
+
+	return service
 }
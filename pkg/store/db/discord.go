@@ -88,7 +88,7 @@ func (s Service) listDiscordUsers(ctx context.Context, userIDs ...string) ([]mod
 
 		item.Kind = model.Discord
 		item.Image = getDiscordImageURL(discordID, avatar)
-		items = append(items, item)
+		items = append(items, withIdentity(item, discordID))
 
 		return nil
 	}, listDiscordQuery, userIDs)
@@ -105,15 +105,31 @@ WHERE
   user_id = $1
 `
 
-func (s Service) UpdateDiscordUser(ctx context.Context, user model.User, id, username, avatar string) (model.User, error) {
-	user.Name = username
-	user.Image = getDiscordImageURL(id, avatar)
+func (s Service) UpdateDiscordUser(ctx context.Context, user model.User, discordUser model.DiscordUser) (model.User, error) {
+	image := getDiscordImageURL(discordUser.ID, discordUser.Avatar)
+	if user.Name == discordUser.Username && user.Image == image {
+		return user, nil
+	}
+
+	user.Name = discordUser.Username
+	user.Image = image
 
-	if err := s.db.One(ctx, discordUpdateUserQuery, user.ID, id, username, avatar); err != nil {
+	if err := s.db.One(ctx, discordUpdateUserQuery, user.ID, discordUser.ID, discordUser.Username, discordUser.Avatar); err != nil {
 		return user, fmt.Errorf("update: %w", err)
 	}
 
-	return s.GetDiscordUser(ctx, id)
+	return user, nil
+}
+
+const discordDeleteUserQuery = `
+DELETE FROM
+  auth.discord
+WHERE
+  user_id = $1
+`
+
+func (s Service) DeleteDiscordUser(ctx context.Context, user model.User) error {
+	return s.db.One(ctx, discordDeleteUserQuery, user.ID)
 }
 
 func getDiscordImageURL(id, avatar string) string {
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ViBiOh/auth/v3/pkg/model"
+	"github.com/jackc/pgx/v5"
+)
+
+const emailCreateRegistrationQuery = `
+INSERT INTO
+  auth.email
+(
+  user_id,
+  email
+) VALUES (
+  $1,
+  $2
+)
+`
+
+func (s Service) CreateEmail(ctx context.Context, invite model.User, email string) (model.User, error) {
+	email = strings.ToLower(email)
+
+	invite.Name = email
+	invite.Email = email
+	invite.Kind = model.Email
+
+	return invite, s.db.One(ctx, emailCreateRegistrationQuery, invite.ID, email)
+}
+
+const emailGetUserQuery = `
+SELECT
+  user_id,
+  email
+FROM
+  auth.email
+WHERE
+  email = $1
+`
+
+func (s Service) GetEmailUser(ctx context.Context, email string) (model.User, error) {
+	var item model.User
+
+	return item, s.db.Get(ctx, func(row pgx.Row) error {
+		err := row.Scan(&item.ID, &item.Email)
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.ErrUnknownUser
+		}
+
+		item.Name = item.Email
+		item.Kind = model.Email
+
+		return err
+	}, emailGetUserQuery, strings.ToLower(email))
+}
+
+const listEmailQuery = `
+SELECT
+  user_id,
+  email
+FROM
+  auth.email
+WHERE
+  user_id = ANY($1)
+`
+
+func (s Service) listEmailUsers(ctx context.Context, userIDs ...string) ([]model.User, error) {
+	var items []model.User
+
+	return items, s.db.List(ctx, func(rows pgx.Rows) error {
+		var item model.User
+
+		if err := rows.Scan(&item.ID, &item.Email); err != nil {
+			return fmt.Errorf("scan: %w", err)
+		}
+
+		item.Name = item.Email
+		item.Kind = model.Email
+		items = append(items, withIdentity(item, item.Email))
+
+		return nil
+	}, listEmailQuery, userIDs)
+}
+
+const emailDeleteUserQuery = `
+DELETE FROM
+  auth.email
+WHERE
+  user_id = $1
+`
+
+func (s Service) DeleteEmailUser(ctx context.Context, user model.User) error {
+	return s.db.One(ctx, emailDeleteUserQuery, user.ID)
+}
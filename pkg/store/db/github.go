@@ -85,7 +85,7 @@ func (s Service) listGithubUsers(ctx context.Context, userIDs ...string) ([]mode
 
 		item.Kind = model.GitHub
 		item.Image = getGitHubImageURL(githubID)
-		items = append(items, item)
+		items = append(items, withIdentity(item, strconv.FormatUint(githubID, 10)))
 
 		return nil
 	}, githubListUsers, userIDs)
@@ -101,11 +101,31 @@ WHERE
   user_id = $1
 `
 
-func (s Service) UpdateGitHubUser(ctx context.Context, user model.User, githubID uint64, githubLogin string) (model.User, error) {
-	user.Name = githubLogin
-	user.Image = getGitHubImageURL(githubID)
+func (s Service) UpdateGitHubUser(ctx context.Context, user model.User, githubUser model.GitHubUser) (model.User, error) {
+	image := getGitHubImageURL(githubUser.ID)
+	if user.Name == githubUser.Login && user.Image == image {
+		return user, nil
+	}
+
+	user.Name = githubUser.Login
+	user.Image = image
+
+	if err := s.db.One(ctx, githubUpdateUserQuery, user.ID, githubUser.ID, githubUser.Login); err != nil {
+		return user, fmt.Errorf("update: %w", err)
+	}
+
+	return user, nil
+}
+
+const githubDeleteUserQuery = `
+DELETE FROM
+  auth.github
+WHERE
+  user_id = $1
+`
 
-	return user, s.db.One(ctx, githubUpdateUserQuery, user.ID, githubID, githubLogin)
+func (s Service) DeleteGitHubUser(ctx context.Context, user model.User) error {
+	return s.db.One(ctx, githubDeleteUserQuery, user.ID)
 }
 
 func getGitHubImageURL(id uint64) string {
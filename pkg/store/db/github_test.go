@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ViBiOh/auth/v3/pkg/mocks"
+	"github.com/ViBiOh/auth/v3/pkg/model"
+	"go.uber.org/mock/gomock"
+)
+
+func TestUpdateGitHubUser(t *testing.T) {
+	t.Parallel()
+
+	user := model.User{ID: "1", Name: "vibioh", Image: getGitHubImageURL(42), Kind: model.GitHub}
+
+	renamed := user
+	renamed.Name = "ViBiOh"
+
+	cases := map[string]struct {
+		githubUser model.GitHubUser
+		want       model.User
+		wantErr    error
+	}{
+		"unchanged": {
+			model.GitHubUser{ID: 42, Login: "vibioh"},
+			user,
+			nil,
+		},
+		"renamed": {
+			model.GitHubUser{ID: 42, Login: "ViBiOh"},
+			renamed,
+			nil,
+		},
+		"error": {
+			model.GitHubUser{ID: 42, Login: "ViBiOh"},
+			renamed,
+			errors.New("timeout"),
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+
+			mockDatabase := mocks.NewDatabase(ctrl)
+
+			instance := Service{db: mockDatabase}
+
+			switch intention {
+			case "renamed":
+				mockDatabase.EXPECT().One(gomock.Any(), gomock.Any(), "1", uint64(42), "ViBiOh").Return(nil)
+			case "error":
+				mockDatabase.EXPECT().One(gomock.Any(), gomock.Any(), "1", uint64(42), "ViBiOh").Return(testCase.wantErr)
+			}
+
+			got, gotErr := instance.UpdateGitHubUser(context.Background(), user, testCase.githubUser)
+
+			failed := false
+
+			if testCase.wantErr == nil && gotErr != nil {
+				failed = true
+			} else if testCase.wantErr != nil && !errors.Is(gotErr, testCase.wantErr) {
+				failed = true
+			} else if !reflect.DeepEqual(got, testCase.want) {
+				failed = true
+			}
+
+			if failed {
+				t.Errorf("UpdateGitHubUser() = (%+v, `%s`), want (%+v, `%s`)", got, gotErr, testCase.want, testCase.wantErr)
+			}
+		})
+	}
+}
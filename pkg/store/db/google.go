@@ -16,12 +16,14 @@ INSERT INTO
   id,
   user_id,
   name,
-  picture
+  picture,
+  email
 ) VALUES (
   $1,
   $2,
   $3,
-  $4
+  $4,
+  $5
 )
 `
 
@@ -29,15 +31,17 @@ func (s Service) CreateGoogle(ctx context.Context, invite model.User, user model
 	invite.Name = user.Name
 	invite.Kind = model.Google
 	invite.Image = user.Picture
+	invite.Email = user.VerifiedEmail()
 
-	return invite, s.db.One(ctx, googleCreateRegistrationQuery, user.Sub, invite.ID, user.Name, user.Picture)
+	return invite, s.db.One(ctx, googleCreateRegistrationQuery, user.Sub, invite.ID, user.Name, user.Picture, invite.Email)
 }
 
 const googleGetUserByIdQuery = `
 SELECT
   user_id,
   name,
-  picture
+  picture,
+  email
 FROM
   auth.google
 WHERE
@@ -48,7 +52,7 @@ func (s Service) GetGoogleUser(ctx context.Context, id string) (model.User, erro
 	var item model.User
 
 	return item, s.db.Get(ctx, func(row pgx.Row) error {
-		err := row.Scan(&item.ID, &item.Name, &item.Image)
+		err := row.Scan(&item.ID, &item.Name, &item.Image, &item.Email)
 
 		if errors.Is(err, pgx.ErrNoRows) {
 			return model.ErrUnknownUser
@@ -64,7 +68,9 @@ const googleListUsers = `
 SELECT
   user_id,
   name,
-  picture
+  picture,
+  email,
+  id
 FROM
   auth.google
 WHERE
@@ -75,14 +81,15 @@ func (s Service) listGoogleUsers(ctx context.Context, userIDs ...string) ([]mode
 	var items []model.User
 
 	return items, s.db.List(ctx, func(rows pgx.Rows) error {
+		var googleID string
 		var item model.User
 
-		if err := rows.Scan(&item.ID, &item.Name, &item.Image); err != nil {
+		if err := rows.Scan(&item.ID, &item.Name, &item.Image, &item.Email, &googleID); err != nil {
 			return fmt.Errorf("scan: %w", err)
 		}
 
 		item.Kind = model.Google
-		items = append(items, item)
+		items = append(items, withIdentity(item, googleID))
 
 		return nil
 	}, googleListUsers, userIDs)
@@ -94,18 +101,37 @@ UPDATE
 SET
   id = $2,
   name = $3,
-  picture = $4
+  picture = $4,
+  email = $5
 WHERE
   user_id = $1
 `
 
-func (s Service) UpdateGoogleUser(ctx context.Context, user model.User, googleID, name, picture string) (model.User, error) {
-	user.Name = name
-	user.Image = picture
+func (s Service) UpdateGoogleUser(ctx context.Context, user model.User, googleUser model.GoogleUser) (model.User, error) {
+	email := googleUser.VerifiedEmail()
 
-	if err := s.db.One(ctx, googleUpdateUserQuery, user.ID, googleID, name, picture); err != nil {
+	if user.Name == googleUser.Name && user.Image == googleUser.Picture && user.Email == email {
+		return user, nil
+	}
+
+	user.Name = googleUser.Name
+	user.Image = googleUser.Picture
+	user.Email = email
+
+	if err := s.db.One(ctx, googleUpdateUserQuery, user.ID, googleUser.Sub, googleUser.Name, googleUser.Picture, email); err != nil {
 		return user, fmt.Errorf("update: %w", err)
 	}
 
-	return s.GetGoogleUser(ctx, googleID)
+	return user, nil
+}
+
+const googleDeleteUserQuery = `
+DELETE FROM
+  auth.google
+WHERE
+  user_id = $1
+`
+
+func (s Service) DeleteGoogleUser(ctx context.Context, user model.User) error {
+	return s.db.One(ctx, googleDeleteUserQuery, user.ID)
 }
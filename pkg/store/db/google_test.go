@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ViBiOh/auth/v3/pkg/mocks"
+	"github.com/ViBiOh/auth/v3/pkg/model"
+	"go.uber.org/mock/gomock"
+)
+
+func TestUpdateGoogleUser(t *testing.T) {
+	t.Parallel()
+
+	user := model.User{ID: "1", Name: "Bob", Image: "picture", Kind: model.Google}
+
+	verified := user
+	verified.Email = "bob@vibioh.fr"
+
+	cases := map[string]struct {
+		googleUser model.GoogleUser
+		want       model.User
+		wantErr    error
+	}{
+		"unchanged": {
+			model.GoogleUser{Sub: "sub", Name: "Bob", Picture: "picture"},
+			user,
+			nil,
+		},
+		"unverified email": {
+			model.GoogleUser{Sub: "sub", Name: "Bob", Picture: "picture", Email: "bob@vibioh.fr"},
+			user,
+			nil,
+		},
+		"verified email": {
+			model.GoogleUser{Sub: "sub", Name: "Bob", Picture: "picture", Email: "bob@vibioh.fr", EmailVerified: true},
+			verified,
+			nil,
+		},
+		"error": {
+			model.GoogleUser{Sub: "sub", Name: "Bob", Picture: "picture", Email: "bob@vibioh.fr", EmailVerified: true},
+			verified,
+			errors.New("timeout"),
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+
+			mockDatabase := mocks.NewDatabase(ctrl)
+
+			instance := Service{db: mockDatabase}
+
+			switch intention {
+			case "verified email":
+				mockDatabase.EXPECT().One(gomock.Any(), gomock.Any(), "1", "sub", "Bob", "picture", "bob@vibioh.fr").Return(nil)
+			case "error":
+				mockDatabase.EXPECT().One(gomock.Any(), gomock.Any(), "1", "sub", "Bob", "picture", "bob@vibioh.fr").Return(testCase.wantErr)
+			}
+
+			got, gotErr := instance.UpdateGoogleUser(context.Background(), user, testCase.googleUser)
+
+			failed := false
+
+			if testCase.wantErr == nil && gotErr != nil {
+				failed = true
+			} else if testCase.wantErr != nil && !errors.Is(gotErr, testCase.wantErr) {
+				failed = true
+			} else if !reflect.DeepEqual(got, testCase.want) {
+				failed = true
+			}
+
+			if failed {
+				t.Errorf("UpdateGoogleUser() = (%+v, `%s`), want (%+v, `%s`)", got, gotErr, testCase.want, testCase.wantErr)
+			}
+		})
+	}
+}
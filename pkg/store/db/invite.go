@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/ViBiOh/auth/v3/pkg/model"
 	"github.com/ViBiOh/httputils/v4/pkg/id"
@@ -16,23 +17,52 @@ INSERT INTO
 (
   user_id,
   token,
-  description
+  description,
+  expiration,
+  max_uses,
+  provider,
+  profiles,
+  creator,
+  email
 ) VALUES (
   $1,
   $2,
-  $3
+  $3,
+  $4,
+  $5,
+  $6,
+  $7,
+  $8,
+  $9
 )
 `
 
-func (s Service) CreateInvite(ctx context.Context, description string) (model.User, string, error) {
-	token := id.New()
-
-	user, err := s.Create(ctx, description)
+func (s Service) CreateInvite(ctx context.Context, invitation model.Invitation) (model.Invitation, error) {
+	user, err := s.Create(ctx, invitation.Description)
 	if err != nil {
-		return user, token, fmt.Errorf("create user: %w", err)
+		return invitation, fmt.Errorf("create user: %w", err)
+	}
+
+	invitation.UserID = user.ID
+	invitation.Token = id.New()
+	invitation.Uses = 0
+	invitation.MaxUses = max(invitation.MaxUses, 1)
+
+	if invitation.Profiles == nil {
+		invitation.Profiles = []string{}
+	}
+
+	var expiration *time.Time
+	if !invitation.Expiration.IsZero() {
+		expiration = &invitation.Expiration
+	}
+
+	var creator *string
+	if len(invitation.Creator) != 0 {
+		creator = &invitation.Creator
 	}
 
-	return user, token, s.db.One(ctx, createInviteQuery, user.ID, token, description)
+	return invitation, s.db.One(ctx, createInviteQuery, invitation.UserID, invitation.Token, invitation.Description, expiration, invitation.MaxUses, invitation.Provider, invitation.Profiles, creator, invitation.Email)
 }
 
 const getInviteByID = `
@@ -60,28 +90,152 @@ func (s Service) GetInviteByID(ctx context.Context, id string) (model.User, erro
 	}, getInviteByID, id)
 }
 
-const getInviteByTokenQuery = `
-SELECT
+const invitationColumns = `
   user_id,
-  description
-FROM
+  token,
+  description,
+  expiration,
+  max_uses,
+  uses,
+  provider,
+  profiles,
+  COALESCE(creator, ''),
+  email,
+  delivery,
+  creation
+`
+
+func scanInvitation(row pgx.Row) (model.Invitation, error) {
+	var item model.Invitation
+	var expiration *time.Time
+
+	if err := row.Scan(&item.UserID, &item.Token, &item.Description, &expiration, &item.MaxUses, &item.Uses, &item.Provider, &item.Profiles, &item.Creator, &item.Email, &item.Delivery, &item.Creation); err != nil {
+		return item, err
+	}
+
+	if expiration != nil {
+		item.Expiration = *expiration
+	}
+
+	return item, nil
+}
+
+const getInviteByTokenQuery = `
+SELECT` + invitationColumns + `FROM
   auth.invite
 WHERE
   token = $1
+  AND uses < max_uses
+  AND (expiration IS NULL OR expiration > now())
 `
 
-func (s Service) GetInviteByToken(ctx context.Context, token string) (model.User, error) {
-	var item model.User
+func (s Service) GetInviteByToken(ctx context.Context, token string) (model.Invitation, error) {
+	return s.getInvitation(ctx, getInviteByTokenQuery, token)
+}
 
-	return item, s.db.Get(ctx, func(row pgx.Row) error {
-		err := row.Scan(&item.ID, &item.Name)
+const getInvitationQuery = `
+SELECT` + invitationColumns + `FROM
+  auth.invite
+WHERE
+  user_id = $1
+`
+
+func (s Service) GetInvitation(ctx context.Context, userID string) (model.Invitation, error) {
+	return s.getInvitation(ctx, getInvitationQuery, userID)
+}
+
+func (s Service) getInvitation(ctx context.Context, query string, args ...any) (model.Invitation, error) {
+	var item model.Invitation
+
+	return item, s.db.Get(ctx, func(row pgx.Row) (err error) {
+		item, err = scanInvitation(row)
 
 		if errors.Is(err, pgx.ErrNoRows) {
 			return model.ErrUnknownUser
 		}
 
 		return err
-	}, getInviteByTokenQuery, token)
+	}, query, args...)
+}
+
+const listInvitationsQuery = `
+SELECT` + invitationColumns + `FROM
+  auth.invite
+ORDER BY
+  creation DESC
+`
+
+func (s Service) ListInvitations(ctx context.Context) ([]model.Invitation, error) {
+	var items []model.Invitation
+
+	return items, s.db.List(ctx, func(rows pgx.Rows) error {
+		item, err := scanInvitation(rows)
+		if err != nil {
+			return fmt.Errorf("scan: %w", err)
+		}
+
+		items = append(items, item)
+
+		return nil
+	}, listInvitationsQuery)
+}
+
+const regenerateInviteQuery = `
+UPDATE
+  auth.invite
+SET
+  token = $2
+WHERE
+  user_id = $1
+`
+
+func (s Service) RegenerateInvite(ctx context.Context, invitation model.Invitation) (model.Invitation, error) {
+	invitation.Token = id.New()
+
+	return invitation, s.db.One(ctx, regenerateInviteQuery, invitation.UserID, invitation.Token)
+}
+
+const updateInviteDeliveryQuery = `
+UPDATE
+  auth.invite
+SET
+  email = $2,
+  delivery = $3
+WHERE
+  user_id = $1
+`
+
+func (s Service) UpdateInviteDelivery(ctx context.Context, invitation model.Invitation) error {
+	return s.db.One(ctx, updateInviteDeliveryQuery, invitation.UserID, invitation.Email, invitation.Delivery)
+}
+
+const revokeInviteQuery = `
+DELETE FROM
+  auth.user u
+USING
+  auth.invite i
+WHERE
+  u.id = i.user_id
+  AND i.user_id = $1
+`
+
+func (s Service) RevokeInvite(ctx context.Context, invitation model.Invitation) error {
+	return s.db.One(ctx, revokeInviteQuery, invitation.UserID)
+}
+
+const useInviteQuery = `
+UPDATE
+  auth.invite
+SET
+  uses = uses + 1
+WHERE
+  token = $1
+  AND uses < max_uses
+  AND (expiration IS NULL OR expiration > now())
+`
+
+func (s Service) UseInvite(ctx context.Context, invitation model.Invitation) error {
+	return s.db.One(ctx, useInviteQuery, invitation.Token)
 }
 
 const listInviteQuery = `
@@ -0,0 +1,149 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ViBiOh/auth/v3/pkg/model"
+	"github.com/jackc/pgx/v5"
+)
+
+const getBasicLoginQuery = `
+SELECT
+  login
+FROM
+  auth.basic
+WHERE
+  user_id = $1
+`
+
+func (s Service) GetBasicLogin(ctx context.Context, user model.User) (string, error) {
+	var login string
+
+	return login, s.db.Get(ctx, func(row pgx.Row) error {
+		err := row.Scan(&login)
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.ErrUnknownUser
+		}
+
+		return err
+	}, getBasicLoginQuery, user.ID)
+}
+
+const getBasicUserByLoginQuery = `
+SELECT
+  user_id,
+  login
+FROM
+  auth.basic
+WHERE
+  login = $1
+`
+
+func (s Service) GetBasicUserByLogin(ctx context.Context, login string) (model.User, error) {
+	var item model.User
+
+	return item, s.db.Get(ctx, func(row pgx.Row) error {
+		err := row.Scan(&item.ID, &item.Name)
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.ErrUnknownUser
+		}
+
+		item.Kind = model.Basic
+
+		return err
+	}, getBasicUserByLoginQuery, strings.ToLower(login))
+}
+
+const savePasswordResetQuery = `
+INSERT INTO
+  auth.password_reset
+(
+  token,
+  user_id,
+  expiration
+) VALUES (
+  $1,
+  $2,
+  $3
+)
+ON CONFLICT (user_id) DO UPDATE SET
+  token = EXCLUDED.token,
+  expiration = EXCLUDED.expiration,
+  creation = now()
+`
+
+func (s Service) SavePasswordReset(ctx context.Context, user model.User, tokenHash string, expiration time.Time) error {
+	return s.db.One(ctx, savePasswordResetQuery, tokenHash, user.ID, expiration)
+}
+
+const consumePasswordResetQuery = `
+DELETE FROM
+  auth.password_reset
+WHERE
+  token = $1
+RETURNING
+  user_id,
+  expiration
+`
+
+func (s Service) ConsumePasswordReset(ctx context.Context, tokenHash string) (model.User, error) {
+	var item model.User
+	var expiration time.Time
+
+	return item, s.db.Get(ctx, func(row pgx.Row) error {
+		err := row.Scan(&item.ID, &expiration)
+
+		if errors.Is(err, pgx.ErrNoRows) || (err == nil && time.Now().After(expiration)) {
+			return model.ErrUnknownUser
+		}
+
+		return err
+	}, consumePasswordResetQuery, tokenHash)
+}
+
+const revokeSessionsQuery = `
+UPDATE
+  auth.basic
+SET
+  revocation = date_trunc('second', now()) + interval '1 second'
+WHERE
+  user_id = $1
+`
+
+func (s Service) RevokeSessions(ctx context.Context, user model.User) error {
+	return s.db.One(ctx, revokeSessionsQuery, user.ID)
+}
+
+const getSessionRevocationQuery = `
+SELECT
+  revocation
+FROM
+  auth.basic
+WHERE
+  user_id = $1
+`
+
+func (s Service) GetSessionRevocation(ctx context.Context, user model.User) (time.Time, error) {
+	var revocation *time.Time
+
+	err := s.db.Get(ctx, func(row pgx.Row) error {
+		err := row.Scan(&revocation)
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.ErrUnknownUser
+		}
+
+		return err
+	}, getSessionRevocationQuery, user.ID)
+
+	if err != nil || revocation == nil {
+		return time.Time{}, err
+	}
+
+	return *revocation, nil
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ViBiOh/auth/v3/pkg/model"
+	"github.com/ViBiOh/httputils/v4/pkg/id"
+	"github.com/jackc/pgx/v5"
+)
+
+const listRecoveryCodesQuery = `
+SELECT
+  id,
+  hash,
+  used
+FROM
+  auth.recovery_code
+WHERE
+  user_id = $1
+`
+
+func (s Service) ListRecoveryCodes(ctx context.Context, user model.User) ([]model.RecoveryCode, error) {
+	var items []model.RecoveryCode
+
+	return items, s.db.List(ctx, func(rows pgx.Rows) error {
+		var item model.RecoveryCode
+		var used *time.Time
+
+		if err := rows.Scan(&item.ID, &item.Hash, &used); err != nil {
+			return fmt.Errorf("scan: %w", err)
+		}
+
+		if used != nil {
+			item.Used = *used
+		}
+
+		items = append(items, item)
+
+		return nil
+	}, listRecoveryCodesQuery, user.ID)
+}
+
+const useRecoveryCodeQuery = `
+UPDATE
+  auth.recovery_code
+SET
+  used = now()
+WHERE
+  id = $1
+  AND used IS NULL
+RETURNING
+  id
+`
+
+func (s Service) UseRecoveryCode(ctx context.Context, code model.RecoveryCode) error {
+	return s.db.Get(ctx, func(row pgx.Row) error {
+		var id string
+		err := row.Scan(&id)
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.ErrInvalidCredentials
+		}
+
+		return err
+	}, useRecoveryCodeQuery, code.ID)
+}
+
+const deleteRecoveryCodesQuery = `
+DELETE FROM
+  auth.recovery_code
+WHERE
+  user_id = $1
+`
+
+const insertRecoveryCodeQuery = `
+INSERT INTO
+  auth.recovery_code
+(
+  id,
+  user_id,
+  hash
+) VALUES (
+  $1,
+  $2,
+  $3
+)
+`
+
+func (s Service) ReplaceRecoveryCodes(ctx context.Context, user model.User, hashes []string) error {
+	return s.db.DoAtomic(ctx, func(ctx context.Context) error {
+		if err := s.db.Exec(ctx, deleteRecoveryCodesQuery, user.ID); err != nil {
+			return fmt.Errorf("delete: %w", err)
+		}
+
+		for _, hash := range hashes {
+			if err := s.db.One(ctx, insertRecoveryCodeQuery, id.New(), user.ID, hash); err != nil {
+				return fmt.Errorf("insert: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
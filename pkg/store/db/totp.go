@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ViBiOh/auth/v3/pkg/model"
+	"github.com/jackc/pgx/v5"
+)
+
+const getTOTPQuery = `
+SELECT
+  secret,
+  last_step,
+  confirmed
+FROM
+  auth.totp
+WHERE
+  user_id = $1
+`
+
+func (s Service) GetTOTP(ctx context.Context, user model.User) (model.TOTP, error) {
+	var item model.TOTP
+
+	return item, s.db.Get(ctx, func(row pgx.Row) error {
+		err := row.Scan(&item.Secret, &item.LastStep, &item.Confirmed)
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.ErrUnknownUser
+		}
+
+		return err
+	}, getTOTPQuery, user.ID)
+}
+
+const saveTOTPQuery = `
+INSERT INTO
+  auth.totp
+(
+  user_id,
+  secret
+) VALUES (
+  $1,
+  $2
+)
+ON CONFLICT (user_id) DO UPDATE SET
+  secret = EXCLUDED.secret,
+  last_step = 0,
+  confirmed = false,
+  creation = now()
+WHERE
+  NOT auth.totp.confirmed
+`
+
+func (s Service) SaveTOTP(ctx context.Context, user model.User, secret []byte) error {
+	return s.db.One(ctx, saveTOTPQuery, user.ID, secret)
+}
+
+const useTOTPStepQuery = `
+UPDATE
+  auth.totp
+SET
+  last_step = $2,
+  confirmed = true
+WHERE
+  user_id = $1
+  AND last_step < $2
+  AND confirmed = $3
+RETURNING
+  user_id
+`
+
+func (s Service) ConfirmTOTP(ctx context.Context, user model.User, step int64) error {
+	return s.useTOTPStep(ctx, user, step, false)
+}
+
+func (s Service) UseTOTPStep(ctx context.Context, user model.User, step int64) error {
+	return s.useTOTPStep(ctx, user, step, true)
+}
+
+func (s Service) useTOTPStep(ctx context.Context, user model.User, step int64, confirmed bool) error {
+	return s.db.Get(ctx, func(row pgx.Row) error {
+		var id string
+		err := row.Scan(&id)
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.ErrInvalidCredentials
+		}
+
+		return err
+	}, useTOTPStepQuery, user.ID, step, confirmed)
+}
+
+const deleteTOTPQuery = `
+DELETE FROM
+  auth.totp
+WHERE
+  user_id = $1
+`
+
+func (s Service) DeleteTOTP(ctx context.Context, user model.User) error {
+	return s.db.One(ctx, deleteTOTPQuery, user.ID)
+}
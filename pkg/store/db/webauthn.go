@@ -0,0 +1,133 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ViBiOh/auth/v3/pkg/model"
+	"github.com/jackc/pgx/v5"
+)
+
+const webauthnColumns = `
+  id,
+  user_id,
+  public_key,
+  name,
+  sign_count,
+  last_used,
+  creation
+`
+
+func scanWebAuthnCredential(row pgx.Row) (model.WebAuthnCredential, error) {
+	var item model.WebAuthnCredential
+	var signCount int64
+	var lastUsed *time.Time
+
+	if err := row.Scan(&item.ID, &item.UserID, &item.PublicKey, &item.Name, &signCount, &lastUsed, &item.Creation); err != nil {
+		return item, err
+	}
+
+	item.SignCount = uint32(signCount)
+
+	if lastUsed != nil {
+		item.LastUsed = *lastUsed
+	}
+
+	return item, nil
+}
+
+const listWebAuthnCredentialsQuery = `
+SELECT` + webauthnColumns + `FROM
+  auth.webauthn_credential
+WHERE
+  user_id = $1
+ORDER BY
+  creation
+`
+
+func (s Service) ListWebAuthnCredentials(ctx context.Context, user model.User) ([]model.WebAuthnCredential, error) {
+	var items []model.WebAuthnCredential
+
+	return items, s.db.List(ctx, func(rows pgx.Rows) error {
+		item, err := scanWebAuthnCredential(rows)
+		if err != nil {
+			return fmt.Errorf("scan: %w", err)
+		}
+
+		items = append(items, item)
+
+		return nil
+	}, listWebAuthnCredentialsQuery, user.ID)
+}
+
+const getWebAuthnCredentialQuery = `
+SELECT` + webauthnColumns + `FROM
+  auth.webauthn_credential
+WHERE
+  id = $1
+`
+
+func (s Service) GetWebAuthnCredential(ctx context.Context, id []byte) (model.WebAuthnCredential, error) {
+	var item model.WebAuthnCredential
+
+	return item, s.db.Get(ctx, func(row pgx.Row) (err error) {
+		item, err = scanWebAuthnCredential(row)
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.ErrUnknownUser
+		}
+
+		return err
+	}, getWebAuthnCredentialQuery, id)
+}
+
+const createWebAuthnCredentialQuery = `
+INSERT INTO
+  auth.webauthn_credential
+(
+  id,
+  user_id,
+  public_key,
+  name,
+  sign_count
+) VALUES (
+  $1,
+  $2,
+  $3,
+  $4,
+  $5
+)
+`
+
+func (s Service) CreateWebAuthnCredential(ctx context.Context, credential model.WebAuthnCredential) error {
+	return s.db.One(ctx, createWebAuthnCredentialQuery, credential.ID, credential.UserID, credential.PublicKey, credential.Name, int64(credential.SignCount))
+}
+
+const updateWebAuthnSignCountQuery = `
+UPDATE
+  auth.webauthn_credential
+SET
+  sign_count = $2,
+  last_used = now()
+WHERE
+  id = $1
+  AND (sign_count < $2 OR $2 = 0)
+`
+
+func (s Service) UpdateWebAuthnSignCount(ctx context.Context, credential model.WebAuthnCredential) error {
+	return s.db.One(ctx, updateWebAuthnSignCountQuery, credential.ID, int64(credential.SignCount))
+}
+
+const deleteWebAuthnCredentialQuery = `
+DELETE FROM
+  auth.webauthn_credential
+WHERE
+  id = $1
+  AND user_id = $2
+`
+
+func (s Service) DeleteWebAuthnCredential(ctx context.Context, user model.User, id []byte) error {
+	return s.db.One(ctx, deleteWebAuthnCredentialQuery, id, user.ID)
+}
@@ -2,25 +2,48 @@ package memory
 
 import (
 	"context"
+	"log/slog"
 	"strings"
 
 	"github.com/ViBiOh/auth/v3/pkg/argon"
 	"github.com/ViBiOh/auth/v3/pkg/model"
 )
 
-func (s Service) GetBasicUser(_ context.Context, login, password string) (model.User, error) {
+func (s Service) GetBasicUser(ctx context.Context, login, password string) (model.User, error) {
 	user, ok := s.identifications[login]
 	if !ok {
 		return model.User{}, model.ErrInvalidCredentials
 	}
 
-	if strings.HasPrefix(string(user.password), "$argon2id") {
-		if argon.CompareHashAndPassword(string(user.password), password) == nil {
+	encoded := string(user.password)
+	if s.upgraded != nil {
+		if upgraded, ok := s.upgraded.Load(login); ok {
+			encoded = upgraded.(string)
+		}
+	}
+
+	if strings.HasPrefix(encoded, "$argon2id") {
+		if argon.CompareHashAndPassword(encoded, password) == nil {
 			return user.User, nil
 		}
+
+		return model.User{}, model.ErrInvalidCredentials
+	}
+
+	if argon.LegacyVerifiers.Verify(encoded, password) != nil {
+		return model.User{}, model.ErrInvalidCredentials
+	}
+
+	if s.upgraded != nil {
+		if upgraded, err := argon.GenerateFromPassword(password); err != nil {
+			slog.LogAttrs(ctx, slog.LevelError, "update password to argon2", slog.Any("error", err))
+		} else {
+			s.upgraded.Store(login, upgraded)
+			slog.LogAttrs(ctx, slog.LevelWarn, "legacy password hash in configuration, replace it with an argon2id one", slog.String("login", login))
+		}
 	}
 
-	return model.User{}, model.ErrInvalidCredentials
+	return user.User, nil
 }
 
 func (s Service) IsAuthorized(_ context.Context, user model.User, profile string) bool {
@@ -147,3 +147,31 @@ func TestIsAuthorized(t *testing.T) {
 		})
 	}
 }
+
+func TestLoginLegacyUpgrade(t *testing.T) {
+	t.Parallel()
+
+	instance, err := New(&Config{Idents: []string{"1:admin:{SSHA}5pcxo13YdJml98eQSIlT3FMEBMMBAgME"}})
+	if err != nil {
+		t.Fatalf("new: %s", err)
+	}
+
+	if _, err := instance.GetBasicUser(context.Background(), "admin", "battery staple"); !errors.Is(err, model.ErrInvalidCredentials) {
+		t.Errorf("GetBasicUser() = `%v`, want `%s`", err, model.ErrInvalidCredentials)
+	}
+
+	if _, ok := instance.upgraded.Load("admin"); ok {
+		t.Error("hash upgraded after a failed login")
+	}
+
+	for range 2 {
+		if _, err := instance.GetBasicUser(context.Background(), "admin", "correct horse"); err != nil {
+			t.Errorf("GetBasicUser() = `%s`", err)
+		}
+	}
+
+	upgraded, ok := instance.upgraded.Load("admin")
+	if !ok || argon.DefaultParams.NeedsRehash(upgraded.(string)) {
+		t.Errorf("upgraded = `%v`, want an argon2id hash", upgraded)
+	}
+}
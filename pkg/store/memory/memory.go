@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/ViBiOh/auth/v3/pkg/model"
 	"github.com/ViBiOh/auth/v3/pkg/provider/basic"
@@ -18,6 +19,7 @@ var (
 type Service struct {
 	identifications map[string]basicUser
 	authorizations  map[string][]string
+	upgraded        *sync.Map
 }
 
 type Config struct {
@@ -48,6 +50,7 @@ func New(config *Config) (Service, error) {
 	return Service{
 		identifications: identifications,
 		authorizations:  authorizations,
+		upgraded:        &sync.Map{},
 	}, nil
 }
 
@@ -0,0 +1,285 @@
+package totp
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ViBiOh/auth/v3/pkg/model"
+	"github.com/ViBiOh/flags"
+	"github.com/ViBiOh/httputils/v4/pkg/httperror"
+	"github.com/ViBiOh/httputils/v4/pkg/httpjson"
+)
+
+type Storage interface {
+	GetTOTP(ctx context.Context, user model.User) (model.TOTP, error)
+	SaveTOTP(ctx context.Context, user model.User, secret []byte) error
+	ConfirmTOTP(ctx context.Context, user model.User, step int64) error
+	UseTOTPStep(ctx context.Context, user model.User, step int64) error
+	DeleteTOTP(ctx context.Context, user model.User) error
+}
+
+type Config struct {
+	issuer string
+	key    string
+}
+
+type Service struct {
+	storage Storage
+	aead    cipher.AEAD
+	issuer  string
+}
+
+type Enrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+	QR     string `json:"qr"`
+}
+
+func Flags(fs *flag.FlagSet, prefix string, overrides ...flags.Override) *Config {
+	var config Config
+
+	flags.New("Issuer", "Issuer displayed in authenticator apps").Prefix(prefix).DocPrefix("totp").StringVar(fs, &config.issuer, "auth", overrides)
+	flags.New("Key", "Hex-encoded AES key for encrypting secrets, 32 bytes").Prefix(prefix).DocPrefix("totp").StringVar(fs, &config.key, "", overrides)
+
+	return &config
+}
+
+func New(config *Config, storage Storage) (Service, error) {
+	if len(config.key) == 0 {
+		return Service{}, ErrNoKey
+	}
+
+	key, err := hex.DecodeString(config.key)
+	if err != nil {
+		return Service{}, fmt.Errorf("decode key: %w", err)
+	}
+
+	if len(key) != 32 {
+		return Service{}, errors.New("key must be 32 bytes long")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return Service{}, fmt.Errorf("cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return Service{}, fmt.Errorf("gcm: %w", err)
+	}
+
+	return Service{
+		storage: storage,
+		aead:    aead,
+		issuer:  config.issuer,
+	}, nil
+}
+
+func (s Service) Enroll(ctx context.Context, user model.User) (Enrollment, error) {
+	confirmed, err := s.Required(ctx, user)
+	if err != nil {
+		return Enrollment{}, err
+	}
+
+	if confirmed {
+		return Enrollment{}, ErrEnrolled
+	}
+
+	secret, err := GenerateSecret()
+	if err != nil {
+		return Enrollment{}, err
+	}
+
+	encrypted, err := s.encrypt(user, secret)
+	if err != nil {
+		return Enrollment{}, err
+	}
+
+	if err := s.storage.SaveTOTP(ctx, user, encrypted); err != nil {
+		return Enrollment{}, fmt.Errorf("save: %w", err)
+	}
+
+	uri := URI(s.issuer, user.Name, secret)
+
+	image, err := QR(uri)
+	if err != nil {
+		return Enrollment{}, fmt.Errorf("qr: %w", err)
+	}
+
+	return Enrollment{
+		Secret: secret,
+		URI:    uri,
+		QR:     "data:image/png;base64," + base64.StdEncoding.EncodeToString(image),
+	}, nil
+}
+
+func (s Service) Confirm(ctx context.Context, user model.User, code string) error {
+	step, err := s.validate(ctx, user, code, false)
+	if err != nil {
+		return err
+	}
+
+	if err := s.storage.ConfirmTOTP(ctx, user, step); err != nil {
+		return fmt.Errorf("confirm: %w", err)
+	}
+
+	return nil
+}
+
+func (s Service) Required(ctx context.Context, user model.User) (bool, error) {
+	item, err := s.storage.GetTOTP(ctx, user)
+	if err != nil {
+		if errors.Is(err, model.ErrUnknownUser) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("get: %w", err)
+	}
+
+	return item.Confirmed, nil
+}
+
+func (s Service) Verify(ctx context.Context, user model.User, code string) error {
+	step, err := s.validate(ctx, user, code, true)
+	if err != nil {
+		return err
+	}
+
+	if err := s.storage.UseTOTPStep(ctx, user, step); err != nil {
+		if errors.Is(err, model.ErrInvalidCredentials) {
+			return ErrReplayedCode
+		}
+
+		return fmt.Errorf("use: %w", err)
+	}
+
+	return nil
+}
+
+func (s Service) Disable(ctx context.Context, user model.User, code string) error {
+	if err := s.Verify(ctx, user, code); err != nil {
+		return err
+	}
+
+	if err := s.storage.DeleteTOTP(ctx, user); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	return nil
+}
+
+func (s Service) validate(ctx context.Context, user model.User, code string, confirmed bool) (int64, error) {
+	item, err := s.storage.GetTOTP(ctx, user)
+	if err != nil {
+		if errors.Is(err, model.ErrUnknownUser) {
+			return 0, ErrNotEnrolled
+		}
+
+		return 0, fmt.Errorf("get: %w", err)
+	}
+
+	if item.Confirmed != confirmed {
+		return 0, ErrNotEnrolled
+	}
+
+	secret, err := s.decrypt(user, item.Secret)
+	if err != nil {
+		return 0, err
+	}
+
+	step, err := Validate(secret, code, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	if step <= item.LastStep {
+		return 0, ErrReplayedCode
+	}
+
+	return step, nil
+}
+
+func (s Service) encrypt(user model.User, secret string) ([]byte, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("nonce: %w", err)
+	}
+
+	return s.aead.Seal(nonce, nonce, []byte(secret), []byte(user.ID)), nil
+}
+
+func (s Service) decrypt(user model.User, payload []byte) (string, error) {
+	size := s.aead.NonceSize()
+	if len(payload) < size {
+		return "", errors.New("encrypted secret too short")
+	}
+
+	secret, err := s.aead.Open(nil, payload[:size], payload[size:], []byte(user.ID))
+	if err != nil {
+		return "", fmt.Errorf("decrypt: %w", err)
+	}
+
+	return string(secret), nil
+}
+
+func (s Service) Mux(prefix string, mux *http.ServeMux) {
+	mux.HandleFunc("POST "+prefix+"/enroll", s.handleEnroll)
+	mux.HandleFunc("POST "+prefix+"/confirm", s.handleCode(s.Confirm))
+	mux.HandleFunc("POST "+prefix+"/disable", s.handleCode(s.Disable))
+}
+
+func (s Service) handleEnroll(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user := model.ReadUser(ctx)
+	if len(user.ID) == 0 {
+		httperror.Unauthorized(ctx, w, errors.New("login is required"))
+		return
+	}
+
+	enrollment, err := s.Enroll(ctx, user)
+	if err != nil {
+		if errors.Is(err, ErrEnrolled) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+
+		httperror.InternalServerError(ctx, w, err)
+		return
+	}
+
+	httpjson.Write(ctx, w, http.StatusOK, enrollment)
+}
+
+func (s Service) handleCode(action func(context.Context, model.User, string) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		user := model.ReadUser(ctx)
+		if len(user.ID) == 0 {
+			httperror.Unauthorized(ctx, w, errors.New("login is required"))
+			return
+		}
+
+		if err := action(ctx, user, r.FormValue("code")); err != nil {
+			if errors.Is(err, ErrInvalidCode) || errors.Is(err, ErrReplayedCode) || errors.Is(err, ErrNotEnrolled) {
+				httperror.BadRequest(ctx, w, err)
+				return
+			}
+
+			httperror.InternalServerError(ctx, w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
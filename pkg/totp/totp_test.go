@@ -0,0 +1,172 @@
+package totp
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ViBiOh/auth/v3/pkg/model"
+)
+
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		time int64
+		want string
+	}{
+		"59": {
+			59,
+			"287082",
+		},
+		"1111111109": {
+			1111111109,
+			"081804",
+		},
+		"1234567890": {
+			1234567890,
+			"005924",
+		},
+		"20000000000": {
+			20000000000,
+			"353130",
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Code(rfcSecret, Step(time.Unix(testCase.time, 0)))
+			if err != nil {
+				t.Fatalf("Code() = `%s`", err)
+			}
+
+			if got != testCase.want {
+				t.Errorf("Code() = `%s`, want `%s`", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestURI(t *testing.T) {
+	t.Parallel()
+
+	got := URI("ViBiOh", "bob", "SECRET")
+
+	if !strings.HasPrefix(got, "otpauth://totp/ViBiOh:bob?") || !strings.Contains(got, "secret=SECRET") || !strings.Contains(got, "issuer=ViBiOh") {
+		t.Errorf("URI() = `%s`", got)
+	}
+}
+
+type testStorage struct {
+	item model.TOTP
+}
+
+func (ts *testStorage) GetTOTP(_ context.Context, _ model.User) (model.TOTP, error) {
+	if ts.item.Secret == nil {
+		return ts.item, model.ErrUnknownUser
+	}
+
+	return ts.item, nil
+}
+
+func (ts *testStorage) SaveTOTP(_ context.Context, _ model.User, secret []byte) error {
+	ts.item = model.TOTP{Secret: secret}
+
+	return nil
+}
+
+func (ts *testStorage) ConfirmTOTP(_ context.Context, _ model.User, step int64) error {
+	ts.item.Confirmed = true
+	ts.item.LastStep = step
+
+	return nil
+}
+
+func (ts *testStorage) UseTOTPStep(_ context.Context, _ model.User, step int64) error {
+	if step <= ts.item.LastStep {
+		return model.ErrInvalidCredentials
+	}
+
+	ts.item.LastStep = step
+
+	return nil
+}
+
+func (ts *testStorage) DeleteTOTP(_ context.Context, _ model.User) error {
+	ts.item = model.TOTP{}
+
+	return nil
+}
+
+func TestService(t *testing.T) {
+	t.Parallel()
+
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	config := Flags(fs, "")
+
+	if err := fs.Parse([]string{"-key", strings.Repeat("ab", 32)}); err != nil {
+		t.Fatalf("parse flags: %s", err)
+	}
+
+	storage := &testStorage{}
+
+	instance, err := New(config, storage)
+	if err != nil {
+		t.Fatalf("New() = `%s`", err)
+	}
+
+	ctx := context.Background()
+	user := model.User{ID: "1", Name: "bob"}
+
+	enrollment, err := instance.Enroll(ctx, user)
+	if err != nil {
+		t.Fatalf("Enroll() = `%s`", err)
+	}
+
+	if strings.Contains(string(storage.item.Secret), enrollment.Secret) {
+		t.Error("Enroll() stored clear secret")
+	}
+
+	if !strings.HasPrefix(enrollment.QR, "data:image/png;base64,") {
+		t.Errorf("Enroll() qr = `%.40s`, want a PNG data URI", enrollment.QR)
+	}
+
+	if required, _ := instance.Required(ctx, user); required {
+		t.Error("Required() = true before confirmation")
+	}
+
+	now := time.Now()
+
+	previous, _ := Code(enrollment.Secret, Step(now)-1)
+	if err := instance.Confirm(ctx, user, previous); err != nil {
+		t.Fatalf("Confirm() = `%s`", err)
+	}
+
+	if required, _ := instance.Required(ctx, user); !required {
+		t.Error("Required() = false after confirmation")
+	}
+
+	if _, err := instance.Enroll(ctx, user); !errors.Is(err, ErrEnrolled) {
+		t.Errorf("Enroll() = `%s`, want `%s`", err, ErrEnrolled)
+	}
+
+	if err := instance.Verify(ctx, user, previous); !errors.Is(err, ErrReplayedCode) {
+		t.Errorf("Verify() = `%s`, want `%s`", err, ErrReplayedCode)
+	}
+
+	if err := instance.Verify(ctx, user, "000000"); !errors.Is(err, ErrInvalidCode) && !errors.Is(err, ErrReplayedCode) {
+		t.Errorf("Verify() = `%s`, want `%s`", err, ErrInvalidCode)
+	}
+
+	current, _ := Code(enrollment.Secret, Step(now))
+	if err := instance.Verify(ctx, user, current); err != nil && current != previous {
+		t.Errorf("Verify() = `%s`", err)
+	}
+}
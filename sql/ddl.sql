@@ -2,7 +2,12 @@
 DROP TABLE IF EXISTS auth.invite;
 DROP TABLE IF EXISTS auth.discord;
 DROP TABLE IF EXISTS auth.google;
+DROP TABLE IF EXISTS auth.email;
 DROP TABLE IF EXISTS auth.github;
+DROP TABLE IF EXISTS auth.recovery_code;
+DROP TABLE IF EXISTS auth.webauthn_credential;
+DROP TABLE IF EXISTS auth.totp;
+DROP TABLE IF EXISTS auth.password_reset;
 DROP TABLE IF EXISTS auth.basic;
 DROP TABLE IF EXISTS auth.user_profile;
 DROP TABLE IF EXISTS auth.profile;
@@ -11,12 +16,22 @@ DROP TABLE IF EXISTS auth.user;
 DROP INDEX IF EXISTS invite_token;
 DROP INDEX IF EXISTS discord_id;
 DROP INDEX IF EXISTS discord_user_id;
+DROP INDEX IF EXISTS github_id;
 DROP INDEX IF EXISTS github_login;
 DROP INDEX IF EXISTS github_user_id;
 DROP INDEX IF EXISTS google_id;
 DROP INDEX IF EXISTS google_user_id;
+DROP INDEX IF EXISTS email_email;
+DROP INDEX IF EXISTS email_user_id;
 DROP INDEX IF EXISTS basic_login;
 DROP INDEX IF EXISTS basic_user_id;
+DROP INDEX IF EXISTS password_reset_token;
+DROP INDEX IF EXISTS password_reset_user_id;
+DROP INDEX IF EXISTS totp_user_id;
+DROP INDEX IF EXISTS recovery_code_id;
+DROP INDEX IF EXISTS recovery_code_user_id;
+DROP INDEX IF EXISTS webauthn_credential_id;
+DROP INDEX IF EXISTS webauthn_credential_user_id;
 DROP INDEX IF EXISTS user_profile_user_id;
 DROP INDEX IF EXISTS profile_id;
 DROP INDEX IF EXISTS user_id;
@@ -54,15 +69,27 @@ CREATE UNIQUE INDEX user_profile_user_id ON auth.user_profile(user_id, profile_i
 
 -- basic
 CREATE TABLE auth.basic (
-  user_id  TEXT                     NOT NULL REFERENCES auth.user(id) ON DELETE CASCADE,
-  login    TEXT                     NOT NULL,
-  password TEXT                     NOT NULL,
-  creation TIMESTAMP WITH TIME ZONE          DEFAULT now()
+  user_id    TEXT                     NOT NULL REFERENCES auth.user(id) ON DELETE CASCADE,
+  login      TEXT                     NOT NULL,
+  password   TEXT                     NOT NULL,
+  revocation TIMESTAMP WITH TIME ZONE,
+  creation   TIMESTAMP WITH TIME ZONE          DEFAULT now()
 );
 
 CREATE UNIQUE INDEX basic_user_id ON auth.basic(user_id);
 CREATE UNIQUE INDEX basic_login   ON auth.basic(login);
 
+-- password_reset
+CREATE TABLE auth.password_reset (
+  token      TEXT                     NOT NULL,
+  user_id    TEXT                     NOT NULL REFERENCES auth.user(id) ON DELETE CASCADE,
+  expiration TIMESTAMP WITH TIME ZONE NOT NULL,
+  creation   TIMESTAMP WITH TIME ZONE          DEFAULT now()
+);
+
+CREATE UNIQUE INDEX password_reset_token   ON auth.password_reset(token);
+CREATE UNIQUE INDEX password_reset_user_id ON auth.password_reset(user_id);
+
 -- github
 CREATE TABLE auth.github (
   user_id  TEXT                     NOT NULL REFERENCES auth.user(id) ON DELETE CASCADE,
@@ -72,8 +99,46 @@ CREATE TABLE auth.github (
 );
 
 CREATE UNIQUE INDEX github_user_id ON auth.github(user_id);
+CREATE UNIQUE INDEX github_id      ON auth.github(id);
 CREATE        INDEX github_login   ON auth.github(login);
 
+-- totp
+CREATE TABLE auth.totp (
+  user_id   TEXT                     NOT NULL REFERENCES auth.user(id) ON DELETE CASCADE,
+  secret    BYTEA                    NOT NULL,
+  last_step BIGINT                   NOT NULL DEFAULT 0,
+  confirmed BOOLEAN                  NOT NULL DEFAULT false,
+  creation  TIMESTAMP WITH TIME ZONE          DEFAULT now()
+);
+
+CREATE UNIQUE INDEX totp_user_id ON auth.totp(user_id);
+
+-- recovery_code
+CREATE TABLE auth.recovery_code (
+  id       TEXT                     NOT NULL,
+  user_id  TEXT                     NOT NULL REFERENCES auth.user(id) ON DELETE CASCADE,
+  hash     TEXT                     NOT NULL,
+  used     TIMESTAMP WITH TIME ZONE,
+  creation TIMESTAMP WITH TIME ZONE          DEFAULT now()
+);
+
+CREATE UNIQUE INDEX recovery_code_id      ON auth.recovery_code(id);
+CREATE        INDEX recovery_code_user_id ON auth.recovery_code(user_id);
+
+-- webauthn_credential
+CREATE TABLE auth.webauthn_credential (
+  id         BYTEA                    NOT NULL,
+  user_id    TEXT                     NOT NULL REFERENCES auth.user(id) ON DELETE CASCADE,
+  public_key BYTEA                    NOT NULL,
+  name       TEXT                     NOT NULL DEFAULT '',
+  sign_count BIGINT                   NOT NULL DEFAULT 0,
+  last_used  TIMESTAMP WITH TIME ZONE,
+  creation   TIMESTAMP WITH TIME ZONE          DEFAULT now()
+);
+
+CREATE UNIQUE INDEX webauthn_credential_id      ON auth.webauthn_credential(id);
+CREATE        INDEX webauthn_credential_user_id ON auth.webauthn_credential(user_id);
+
 -- discord
 CREATE TABLE auth.discord (
   user_id  TEXT                     NOT NULL REFERENCES auth.user(id) ON DELETE CASCADE,
@@ -84,7 +149,7 @@ CREATE TABLE auth.discord (
 );
 
 CREATE UNIQUE INDEX discord_user_id ON auth.discord(user_id);
-CREATE        INDEX discord_id      ON auth.discord(id);
+CREATE UNIQUE INDEX discord_id      ON auth.discord(id);
 
 -- google
 CREATE TABLE auth.google (
@@ -92,17 +157,36 @@ CREATE TABLE auth.google (
   id       TEXT                     NOT NULL,
   name     TEXT                     NOT NULL,
   picture  TEXT                     NOT NULL,
+  email    TEXT                     NOT NULL DEFAULT '',
   creation TIMESTAMP WITH TIME ZONE          DEFAULT now()
 );
 
 CREATE UNIQUE INDEX google_user_id ON auth.google(user_id);
-CREATE        INDEX google_id      ON auth.google(id);
+CREATE UNIQUE INDEX google_id      ON auth.google(id);
+
+-- email
+CREATE TABLE auth.email (
+  user_id  TEXT                     NOT NULL REFERENCES auth.user(id) ON DELETE CASCADE,
+  email    TEXT                     NOT NULL,
+  creation TIMESTAMP WITH TIME ZONE          DEFAULT now()
+);
+
+CREATE UNIQUE INDEX email_user_id ON auth.email(user_id);
+CREATE UNIQUE INDEX email_email   ON auth.email(email);
 
 -- invite
 CREATE TABLE auth.invite (
   user_id     TEXT                     NOT NULL REFERENCES auth.user(id) ON DELETE CASCADE,
   token       TEXT                     NOT NULL,
   description TEXT                     NOT NULL,
+  expiration  TIMESTAMP WITH TIME ZONE,
+  max_uses    INTEGER                  NOT NULL DEFAULT 1,
+  uses        INTEGER                  NOT NULL DEFAULT 0,
+  provider    TEXT                     NOT NULL DEFAULT '',
+  profiles    TEXT[]                   NOT NULL DEFAULT '{}',
+  creator     TEXT                              REFERENCES auth.user(id) ON DELETE SET NULL,
+  email       TEXT                     NOT NULL DEFAULT '',
+  delivery    TEXT                     NOT NULL DEFAULT '',
   creation    TIMESTAMP WITH TIME ZONE          DEFAULT now()
 );
 
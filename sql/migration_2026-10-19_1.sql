@@ -0,0 +1,2 @@
+ALTER TABLE auth.google
+  ADD COLUMN email TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS discord_id;
+DROP INDEX IF EXISTS google_id;
+
+CREATE UNIQUE INDEX github_id  ON auth.github(id);
+CREATE UNIQUE INDEX discord_id ON auth.discord(id);
+CREATE UNIQUE INDEX google_id  ON auth.google(id);
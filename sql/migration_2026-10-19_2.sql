@@ -0,0 +1,6 @@
+ALTER TABLE auth.invite
+  ADD COLUMN expiration TIMESTAMP WITH TIME ZONE,
+  ADD COLUMN max_uses   INTEGER                  NOT NULL DEFAULT 1,
+  ADD COLUMN uses       INTEGER                  NOT NULL DEFAULT 0,
+  ADD COLUMN provider   TEXT                     NOT NULL DEFAULT '',
+  ADD COLUMN profiles   TEXT[]                   NOT NULL DEFAULT '{}';
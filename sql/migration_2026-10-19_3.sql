@@ -0,0 +1,2 @@
+ALTER TABLE auth.invite
+  ADD COLUMN creator TEXT REFERENCES auth.user(id) ON DELETE SET NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE auth.invite
+  ADD COLUMN email    TEXT NOT NULL DEFAULT '',
+  ADD COLUMN delivery TEXT NOT NULL DEFAULT '';
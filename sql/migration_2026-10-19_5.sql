@@ -0,0 +1,8 @@
+CREATE TABLE auth.email (
+  user_id  TEXT                     NOT NULL REFERENCES auth.user(id) ON DELETE CASCADE,
+  email    TEXT                     NOT NULL,
+  creation TIMESTAMP WITH TIME ZONE          DEFAULT now()
+);
+
+CREATE UNIQUE INDEX email_user_id ON auth.email(user_id);
+CREATE UNIQUE INDEX email_email   ON auth.email(email);
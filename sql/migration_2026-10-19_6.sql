@@ -0,0 +1,9 @@
+CREATE TABLE auth.totp (
+  user_id   TEXT                     NOT NULL REFERENCES auth.user(id) ON DELETE CASCADE,
+  secret    BYTEA                    NOT NULL,
+  last_step BIGINT                   NOT NULL DEFAULT 0,
+  confirmed BOOLEAN                  NOT NULL DEFAULT false,
+  creation  TIMESTAMP WITH TIME ZONE          DEFAULT now()
+);
+
+CREATE UNIQUE INDEX totp_user_id ON auth.totp(user_id);
@@ -0,0 +1,10 @@
+CREATE TABLE auth.recovery_code (
+  id       TEXT                     NOT NULL,
+  user_id  TEXT                     NOT NULL REFERENCES auth.user(id) ON DELETE CASCADE,
+  hash     TEXT                     NOT NULL,
+  used     TIMESTAMP WITH TIME ZONE,
+  creation TIMESTAMP WITH TIME ZONE          DEFAULT now()
+);
+
+CREATE UNIQUE INDEX recovery_code_id      ON auth.recovery_code(id);
+CREATE        INDEX recovery_code_user_id ON auth.recovery_code(user_id);
@@ -0,0 +1,12 @@
+CREATE TABLE auth.webauthn_credential (
+  id         BYTEA                    NOT NULL,
+  user_id    TEXT                     NOT NULL REFERENCES auth.user(id) ON DELETE CASCADE,
+  public_key BYTEA                    NOT NULL,
+  name       TEXT                     NOT NULL DEFAULT '',
+  sign_count BIGINT                   NOT NULL DEFAULT 0,
+  last_used  TIMESTAMP WITH TIME ZONE,
+  creation   TIMESTAMP WITH TIME ZONE          DEFAULT now()
+);
+
+CREATE UNIQUE INDEX webauthn_credential_id      ON auth.webauthn_credential(id);
+CREATE        INDEX webauthn_credential_user_id ON auth.webauthn_credential(user_id);
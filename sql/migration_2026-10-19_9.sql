@@ -0,0 +1,11 @@
+ALTER TABLE auth.basic ADD COLUMN revocation TIMESTAMP WITH TIME ZONE;
+
+CREATE TABLE auth.password_reset (
+  token      TEXT                     NOT NULL,
+  user_id    TEXT                     NOT NULL REFERENCES auth.user(id) ON DELETE CASCADE,
+  expiration TIMESTAMP WITH TIME ZONE NOT NULL,
+  creation   TIMESTAMP WITH TIME ZONE          DEFAULT now()
+);
+
+CREATE UNIQUE INDEX password_reset_token   ON auth.password_reset(token);
+CREATE UNIQUE INDEX password_reset_user_id ON auth.password_reset(user_id);